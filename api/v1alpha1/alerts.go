@@ -0,0 +1,28 @@
+package v1alpha1
+
+const (
+	// AlertsForLabel marks the Secret that holds the token a director's
+	// health monitor uses to forward alerts to Gluon, and names the
+	// director it belongs to.  AlertsScopeLabel is set to "cluster" for
+	// cluster directors.
+	AlertsForLabel   = "gluon.starkandwayne.com/alerts-for"
+	AlertsScopeLabel = "gluon.starkandwayne.com/alerts-scope"
+
+	// AlertsOpsFile is the key, in the ConfigMap named by AlertsName(),
+	// of the ops file that points a director's health monitor at Gluon.
+	AlertsOpsFile = "forward-alerts.yml"
+)
+
+// AlertsName returns the name of the Secret (holding the token) and the
+// ConfigMap (holding the ops file) for forwarding this director's health
+// monitor alerts to Gluon.
+func (bd *BOSHDeployment) AlertsName() string {
+	return bd.Name + "-alerts"
+}
+
+// AlertsName returns the name of the Secret (holding the token) and the
+// ConfigMap (holding the ops file) for forwarding this director's health
+// monitor alerts to Gluon.  Both live in the Gluon namespace.
+func (cbd *ClusterBOSHDirector) AlertsName() string {
+	return cbd.Name + "-cluster-alerts"
+}
@@ -0,0 +1,270 @@
+/*
+Gluon - BOSH / CF Orchestration via Kuberenetes API(s)
+
+Copyright (c) 2020 James Hunt
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to
+deal in the Software without restriction, including without limitation the
+rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+sell copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software..
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+IN THE SOFTWARE.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// ArtifactCacheLabel marks the Deployment (and its Pods) that serve
+	// a BOSHArtifactCache.
+	ArtifactCacheLabel = "gluon.starkandwayne.com/artifact-cache"
+
+	// ArtifactCachePort is the port that artifact caches listen on.
+	ArtifactCachePort = 8080
+)
+
+// BOSHArtifactCacheSpec defines the desired state of BOSHArtifactCache
+type BOSHArtifactCacheSpec struct {
+	// Size is how much storage to ask for, for the cache's
+	// PersistentVolumeClaim.
+	Size resource.Quantity `json:"size"`
+
+	// StorageClassName picks the storage class for the volume; the
+	// cluster's default, unless told otherwise.
+	StorageClassName *string `json:"storageClassName,omitempty"`
+
+	// MaxSize is how big the cache may grow before the least recently
+	// used artifacts are evicted; 90% of Size, unless told otherwise.
+	MaxSize *resource.Quantity `json:"maxSize,omitempty"`
+
+	// Offline caches never fetch anything from upstream, and only
+	// serve artifacts that they have been seeded with (for air-gapped
+	// clusters).
+	Offline bool `json:"offline,omitempty"`
+}
+
+// BOSHArtifactCacheStatus defines the observed state of BOSHArtifactCache
+type BOSHArtifactCacheStatus struct {
+	Ready bool   `json:"ready"`
+	State string `json:"state"`
+
+	// URL is where the cache can be reached, from inside the cluster.
+	URL string `json:"url,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// BOSHArtifactCache is the Schema for the boshartifactcaches API
+// +kubebuilder:resource:path=boshartifactcaches,scope=Namespaced,shortName=bac
+// +kubebuilder:printcolumn:name="Ready",type="boolean",JSONPath=".status.ready"
+// +kubebuilder:printcolumn:name="Size",type="string",JSONPath=".spec.size"
+// +kubebuilder:printcolumn:name="URL",type="string",JSONPath=".status.url"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type BOSHArtifactCache struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   BOSHArtifactCacheSpec   `json:"spec,omitempty"`
+	Status BOSHArtifactCacheStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// BOSHArtifactCacheList contains a list of BOSHArtifactCache
+type BOSHArtifactCacheList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BOSHArtifactCache `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&BOSHArtifactCache{}, &BOSHArtifactCacheList{})
+}
+
+// ResourceName returns the name of the PersistentVolumeClaim, Deployment
+// and Service that make up the cache.
+func (c *BOSHArtifactCache) ResourceName() string {
+	return fmt.Sprintf("%s-artifacts", c.Name)
+}
+
+// URL returns where the cache can be reached, from inside the cluster.
+func (c *BOSHArtifactCache) URL() string {
+	return fmt.Sprintf("http://%s.%s.svc", c.ResourceName(), c.Namespace)
+}
+
+// MaxSize returns how big (in bytes) the cache may grow.
+func (c *BOSHArtifactCache) MaxSize() int64 {
+	if c.Spec.MaxSize != nil {
+		return c.Spec.MaxSize.Value()
+	}
+	return c.Spec.Size.Value() / 10 * 9
+}
+
+func (c *BOSHArtifactCache) labels() map[string]string {
+	return map[string]string{ArtifactCacheLabel: c.Name}
+}
+
+// uploadArtifact wraps a command that uploads the artifact at url.  With
+// an artifact cache, the Job fetches the artifact through it (see
+// fetchArtifact); without one, the director fetches url itself, as it
+// always has, and checks it against digests.
+func uploadArtifact(cache, digests, url string, command []string) []string {
+	if cache != "" {
+		return fetchArtifact(cache, digests, url, command)
+	}
+	if digests != "" {
+		command = append(command, "--sha1", digests)
+	}
+	return command
+}
+
+// fetchArtifact wraps a command that uploads the artifact at url, so
+// that it uploads a local copy instead, once that has been checked
+// against digests.  The copy is fetched through the artifact cache at
+// cache, or straight from url if cache is empty.
+func fetchArtifact(cache, digests, url string, command []string) []string {
+	if cache == "" {
+		cache = "-"
+	}
+	return append([]string{"fetch-artifact", cache, digests, url}, command...)
+}
+
+// PersistentVolumeClaim returns the volume claim that the cache keeps its
+// artifacts on.
+func (c *BOSHArtifactCache) PersistentVolumeClaim() *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: c.Namespace,
+			Name:      c.ResourceName(),
+			Labels:    c.labels(),
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			StorageClassName: c.Spec.StorageClassName,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: c.Spec.Size,
+				},
+			},
+		},
+	}
+}
+
+// Deployment returns the Deployment that serves the cache.  There is only
+// ever the one replica, since the volume can only be mounted once.
+func (c *BOSHArtifactCache) Deployment() *appsv1.Deployment {
+	var one int32 = 1
+	// the cache runs as distroless' nonroot user; freshly provisioned
+	// volumes are usually owned by root, so have Kubernetes hand the
+	// volume over to nonroot's group
+	var nonroot int64 = 65532
+	args := []string{
+		"--dir", "/cache",
+		"--addr", fmt.Sprintf(":%d", ArtifactCachePort),
+		"--max-size", fmt.Sprintf("%d", c.MaxSize()),
+	}
+	if c.Spec.Offline {
+		args = append(args, "--offline")
+	}
+
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: c.Namespace,
+			Name:      c.ResourceName(),
+			Labels:    c.labels(),
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &one,
+			Selector: &metav1.LabelSelector{MatchLabels: c.labels()},
+			Strategy: appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: c.labels()},
+				Spec: corev1.PodSpec{
+					SecurityContext: &corev1.PodSecurityContext{
+						FSGroup: &nonroot,
+					},
+					Containers: []corev1.Container{
+						corev1.Container{
+							Name:            "artifact-cache",
+							Image:           CacheImage,
+							ImagePullPolicy: GluonPullPolicy,
+							Command:         []string{"/artifact-cache"},
+							Args:            args,
+							Ports: []corev1.ContainerPort{
+								corev1.ContainerPort{
+									Name:          "http",
+									ContainerPort: ArtifactCachePort,
+								},
+							},
+							ReadinessProbe: &corev1.Probe{
+								Handler: corev1.Handler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Path: "/healthz",
+										Port: intstr.FromString("http"),
+									},
+								},
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								corev1.VolumeMount{
+									Name:      "cache",
+									MountPath: "/cache",
+								},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						corev1.Volume{
+							Name: "cache",
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: c.ResourceName(),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Service returns the Service that upload Jobs reach the cache through.
+func (c *BOSHArtifactCache) Service() *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: c.Namespace,
+			Name:      c.ResourceName(),
+			Labels:    c.labels(),
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: c.labels(),
+			Ports: []corev1.ServicePort{
+				corev1.ServicePort{
+					Name:       "http",
+					Port:       80,
+					TargetPort: intstr.FromString("http"),
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,240 @@
+/*
+Gluon - BOSH / CF Orchestration via Kuberenetes API(s)
+
+Copyright (c) 2020 James Hunt
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to
+deal in the Software without restriction, including without limitation the
+rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+sell copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software..
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+IN THE SOFTWARE.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BOSHCompiledReleaseSpec defines the desired state of BOSHCompiledRelease
+type BOSHCompiledReleaseSpec struct {
+	// Director or ClusterDirector is where the release is compiled,
+	// and exported from.
+	Director        string `json:"director,omitempty"`
+	ClusterDirector string `json:"clusterDirector,omitempty"`
+
+	// Deployment is a BOSH deployment on that director that uses both
+	// the release and the stemcell; `bosh export-release` needs one.
+	Deployment string `json:"deployment"`
+
+	// Release and Stemcell pick what to export: the release, compiled
+	// against the stemcell.
+	Release  ReleaseRef  `json:"release"`
+	Stemcell StemcellRef `json:"stemcell"`
+
+	// Cache names the BOSHArtifactCache (in the same namespace) that
+	// the compiled release tarball is kept in.
+	Cache string `json:"cache"`
+
+	// UploadTo picks the directors to upload the compiled release to.
+	UploadTo DirectorTargets `json:"uploadTo,omitempty"`
+
+	Fix bool `json:"fix,omitempty"`
+}
+
+// ReleaseRef identifies a release version.
+type ReleaseRef struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+func (r ReleaseRef) String() string {
+	return r.Name + "/" + r.Version
+}
+
+// StemcellRef identifies a stemcell by operating system and version,
+// which is what compiled packages are tied to.
+type StemcellRef struct {
+	OS      string `json:"os"`
+	Version string `json:"version"`
+}
+
+func (s StemcellRef) String() string {
+	return s.OS + "/" + s.Version
+}
+
+// BOSHCompiledReleaseStatus defines the observed state of BOSHCompiledRelease
+type BOSHCompiledReleaseStatus struct {
+	Ready bool   `json:"ready"`
+	State string `json:"state"`
+
+	// CurrentTask follows the director task started by the most
+	// recent Job, as it runs (on any one of the directors).
+	CurrentTask *TaskStatus `json:"currentTask,omitempty"`
+
+	// Export tracks the export from the compiling director.
+	Export DirectorStatus `json:"export"`
+
+	// Exported is the compiled release tarball, once exported.
+	Exported *ExportedRelease `json:"exported,omitempty"`
+
+	// Directors tracks the upload to each of the other directors.
+	Directors []ReleaseDirectorStatus `json:"directors,omitempty"`
+
+	// CacheURL is where the artifact cache named in the spec can be
+	// reached (once it is ready).
+	CacheURL string `json:"cacheURL,omitempty"`
+}
+
+// ExportedRelease is a compiled release tarball, in the artifact cache.
+type ExportedRelease struct {
+	Release  ReleaseRef  `json:"release"`
+	Stemcell StemcellRef `json:"stemcell"`
+
+	// URL is where the tarball can be fetched from, and Digest what it
+	// measured up to be, i.e. "sha1:a1b2...;sha256:4e9f...".
+	URL    string `json:"url"`
+	Digest string `json:"digest"`
+}
+
+// +kubebuilder:object:root=true
+
+// BOSHCompiledRelease is the Schema for the boshcompiledreleases API
+// +kubebuilder:resource:path=boshcompiledreleases,scope=Namespaced,shortName=bcr
+// +kubebuilder:printcolumn:name="Ready",type="boolean",JSONPath=".status.ready"
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="Release",type="string",JSONPath=".spec.release.name"
+// +kubebuilder:printcolumn:name="Stemcell",type="string",JSONPath=".spec.stemcell.os"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type BOSHCompiledRelease struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec         BOSHCompiledReleaseSpec   `json:"spec,omitempty"`
+	Dependencies DependencySpecs           `json:"dependencies,omitempty"`
+	Status       BOSHCompiledReleaseStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// BOSHCompiledReleaseList contains a list of BOSHCompiledRelease
+type BOSHCompiledReleaseList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BOSHCompiledRelease `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&BOSHCompiledRelease{}, &BOSHCompiledReleaseList{})
+}
+
+func (cr *BOSHCompiledRelease) ExportJobName() string {
+	return fmt.Sprintf("export-%s", cr.Name)
+}
+
+func (cr *BOSHCompiledRelease) UploadJobName(director Director) string {
+	return fmt.Sprintf("upload-compiled-%s-to-%s", cr.Name, jobTarget(director))
+}
+
+// DirectorStatus returns the status of the upload to the given director,
+// starting one if need be.
+func (cr *BOSHCompiledRelease) DirectorStatus(director Director) *ReleaseDirectorStatus {
+	for i := range cr.Status.Directors {
+		if cr.Status.Directors[i].Is(director) {
+			return &cr.Status.Directors[i]
+		}
+	}
+	cr.Status.Directors = append(cr.Status.Directors, ReleaseDirectorStatus{DirectorStatus: newDirectorStatus(director)})
+	return &cr.Status.Directors[len(cr.Status.Directors)-1]
+}
+
+// Matches returns true if the release was exported for the release and
+// stemcell that the spec asks for.
+func (e *ExportedRelease) Matches(release ReleaseRef, stemcell StemcellRef) bool {
+	return e.Release == release && e.Stemcell == stemcell
+}
+
+// ExportJob returns a Job that compiles the release against the stemcell
+// on the director (via `bosh export-release`), and stores the result in
+// the artifact cache.
+func (cr *BOSHCompiledRelease) ExportJob(director Director) *batchv1.Job {
+	return cr.job(director, cr.ExportJobName(), "export", []string{
+		"export-release",
+		cr.Status.CacheURL,
+		cr.Spec.Deployment,
+		cr.Spec.Release.String(),
+		cr.Spec.Stemcell.String(),
+	})
+}
+
+// UploadJob returns a Job that uploads the exported release to another
+// director.
+func (cr *BOSHCompiledRelease) UploadJob(director Director) *batchv1.Job {
+	exported := cr.Status.Exported
+	command := []string{
+		"track-task", "bosh", "upload-release", exported.URL,
+		"--name", exported.Release.Name,
+		"--version", exported.Release.Version,
+	}
+	if cr.Spec.Fix {
+		command = append(command, "--fix")
+	}
+	// (the tarball is already in the cache, so there is no need to
+	// go through it)
+	return cr.job(director, cr.UploadJobName(director), "upload",
+		fetchArtifact("", exported.Digest, exported.URL, command))
+}
+
+func (cr *BOSHCompiledRelease) job(director Director, name, container string, command []string) *batchv1.Job {
+	var one int32 = 1
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cr.ObjectMeta.Namespace,
+			Name:      name,
+			Labels:    jobLabels(director, OperationRelease),
+		},
+		Spec: batchv1.JobSpec{
+			Parallelism:  &one,
+			Completions:  &one,
+			BackoffLimit: &one,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						corev1.Container{
+							Name:            container,
+							Image:           GluonImage,
+							ImagePullPolicy: GluonPullPolicy,
+							Command:         command,
+							Env:             append(taskEnv(), directorEnv(director.SecretsName())...),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// ExportedURL returns where, in the artifact cache at cache, the compiled
+// release with the given digests is kept.
+func ExportedURL(cache string, digests Digests) string {
+	d := digests.Strongest()
+	return fmt.Sprintf("%s/artifacts/%s/%s", strings.TrimSuffix(cache, "/"), d.Algorithm, d.Value)
+}
@@ -37,22 +37,53 @@ import (
 
 // BOSHConfigSpec defines the desired state of BOSHConfig
 type BOSHConfigSpec struct {
-	Director string `json:"director"`
+	Director        string `json:"director,omitempty"`
+	ClusterDirector string `json:"clusterDirector,omitempty"`
 
 	Type   string `json:"type"`
 	Config string `json:"config"`
+
+	// DeletionPolicy determines whether the config is removed from
+	// the director (Delete, the default) or left alone (Orphan and
+	// Retain) when this BOSHConfig is deleted.
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// Cancel, if set, cancels the director task that is currently
+	// running on behalf of this BOSHConfig (if any), and keeps Gluon from
+	// starting another one until it is unset.
+	Cancel bool `json:"cancel,omitempty"`
 }
 
 // BOSHConfigStatus defines the observed state of BOSHConfig
 type BOSHConfigStatus struct {
 	Ready bool   `json:"ready"`
 	State string `json:"state"`
+
+	// CurrentTask follows the director task started by the most
+	// recent Job, as it runs.
+	CurrentTask *TaskStatus `json:"currentTask,omitempty"`
+
+	// Revision identifies the content (see BOSHConfig.Revision) that the
+	// most recent update Job was started for.
+	Revision string `json:"revision,omitempty"`
+
+	// ConfigID is the ID the director gave the config, once Revision
+	// was applied, and UpdatedAt is when that happened.  Both change
+	// every time the config does.
+	ConfigID  string       `json:"configID,omitempty"`
+	UpdatedAt *metav1.Time `json:"updatedAt,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 
 // BOSHConfig is the Schema for the boshconfigs API
 // +kubebuilder:resource:path=boshconfigs,scope=Namespaced,shortName=bcc
+// +kubebuilder:printcolumn:name="Ready",type="boolean",JSONPath=".status.ready"
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="Revision",type="string",JSONPath=".status.revision"
+// +kubebuilder:printcolumn:name="Config ID",type="string",JSONPath=".status.configID",priority=1
+// +kubebuilder:printcolumn:name="Task",type="string",JSONPath=".status.currentTask.summary"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 type BOSHConfig struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -75,14 +106,54 @@ func init() {
 	SchemeBuilder.Register(&BOSHConfig{}, &BOSHConfigList{})
 }
 
-func (bc *BOSHConfig) JobName(director *BOSHDeployment) string {
-	return fmt.Sprintf("update-config-%s-on-%s", bc.Name, director.Name)
+// Revision identifies the content of the config (its type, name and
+// YAML), so that each change to it gets its own update Job.
+func (bc *BOSHConfig) Revision() string {
+	return Revision(bc.Spec.Type, bc.ConfigName(), bc.Spec.Config)
+}
+
+func (bc *BOSHConfig) JobName(director Director) string {
+	return bc.RevisionJobName(director, bc.Revision())
+}
+
+// RevisionJobName returns the name of the Job that updates the director
+// to the given revision of the config.
+func (bc *BOSHConfig) RevisionJobName(director Director, revision string) string {
+	return fmt.Sprintf("update-config-%s-on-%s-%s", bc.Name, director.GetName(), revision)
 }
 
-func (bc *BOSHConfig) Job(director *BOSHDeployment) *batchv1.Job {
+func (bc *BOSHConfig) DeleteJobName(director Director) string {
+	return fmt.Sprintf("delete-config-%s-on-%s", bc.Name, director.GetName())
+}
+
+// ConfigName returns the name the config goes by on the director.
+func (bc *BOSHConfig) ConfigName() string {
+	if bc.Spec.Type == "cloud" {
+		// cloud configs are always updated without a --name
+		return "default"
+	}
+	return bc.Name
+}
+
+// DeleteJob returns a Job that removes the config from the director.
+func (bc *BOSHConfig) DeleteJob(director Director) *batchv1.Job {
+	job := bc.Job(director)
+	job.Name = bc.DeleteJobName(director)
+	container := &job.Spec.Template.Spec.Containers[0]
+	container.Name = "delete-config"
+	container.Command = []string{
+		"track-task", "bosh", "-n", "delete-config",
+		"--type", bc.Spec.Type,
+		"--name", bc.ConfigName(),
+	}
+	return job
+}
+
+func (bc *BOSHConfig) Job(director Director) *batchv1.Job {
 	secret := director.SecretsName()
 
 	command := []string{
+		"track-task",
 		"bosh",
 		"-n",
 		fmt.Sprintf("update-%s-config", bc.Spec.Type),
@@ -98,6 +169,7 @@ func (bc *BOSHConfig) Job(director *BOSHDeployment) *batchv1.Job {
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: bc.Namespace,
 			Name:      bc.JobName(director),
+			Labels:    jobLabels(director, OperationConfig),
 		},
 		Spec: batchv1.JobSpec{
 			Parallelism:  &one,
@@ -132,7 +204,7 @@ func (bc *BOSHConfig) Job(director *BOSHDeployment) *batchv1.Job {
 									ReadOnly:  true,
 								},
 							},
-							Env: []corev1.EnvVar{
+							Env: append(taskEnv(),
 								corev1.EnvVar{
 									Name: "BOSH_ENVIRONMENT",
 									ValueFrom: &corev1.EnvVarSource{
@@ -177,7 +249,7 @@ func (bc *BOSHConfig) Job(director *BOSHDeployment) *batchv1.Job {
 										},
 									},
 								},
-							},
+							),
 						},
 					},
 				},
@@ -0,0 +1,56 @@
+/*
+Gluon - BOSH / CF Orchestration via Kuberenetes API(s)
+
+Copyright (c) 2020 James Hunt
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to
+deal in the Software without restriction, including without limitation the
+rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+sell copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software..
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+IN THE SOFTWARE.
+*/
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+func (r *BOSHConfig) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:verbs=delete,path=/validate-gluon-starkandwayne-com-v1alpha1-boshconfig,mutating=false,failurePolicy=fail,groups=gluon.starkandwayne.com,resources=boshconfigs,versions=v1alpha1,name=vboshconfig.gluon.starkandwayne.com
+
+var _ webhook.Validator = &BOSHConfig{}
+
+// ValidateCreate implements webhook.Validator
+func (r *BOSHConfig) ValidateCreate() error {
+	return nil
+}
+
+// ValidateUpdate implements webhook.Validator
+func (r *BOSHConfig) ValidateUpdate(old runtime.Object) error {
+	return nil
+}
+
+// ValidateDelete implements webhook.Validator
+func (r *BOSHConfig) ValidateDelete() error {
+	return validateDelete("BOSHConfig", r)
+}
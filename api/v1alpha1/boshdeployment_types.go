@@ -61,22 +61,126 @@ type BOSHDeploymentSpec struct {
 	Ref        string `json:"ref"`
 	Entrypoint string `json:"entrypoint"`
 
-	Director string `json:"director,omitempty"`
+	Director        string `json:"director,omitempty"`
+	ClusterDirector string `json:"clusterDirector,omitempty"`
 
 	Ops  []string         `json:"ops,omitempty"`
 	Vars []VariableSource `json:"vars,omitempty"`
+
+	// Concurrency limits how many operations Gluon will run at once
+	// against this deployment, if it is itself a BOSH director.
+	Concurrency *ConcurrencySpec `json:"concurrency,omitempty"`
+
+	// StateBackend determines where a BOSH director deployed via
+	// `bosh create-env` keeps its state.json and vars-store; either
+	// on a persistent `volume` (the default), or in a `secret`.
+	// +kubebuilder:validation:Enum=volume;secret
+	StateBackend string `json:"stateBackend,omitempty"`
+
+	// Upgrade controls how changes to the ref of a BOSH director that
+	// was deployed via `bosh create-env` get rolled out.
+	Upgrade *UpgradeSpec `json:"upgrade,omitempty"`
+
+	// ForwardAlerts, if set, configures the health monitor of a BOSH
+	// director deployed via `bosh create-env` to forward its alerts
+	// to Gluon, which records them against the BOSHDeployments that
+	// they are about.
+	ForwardAlerts bool `json:"forwardAlerts,omitempty"`
+
+	// WatchEvents, if set, has Gluon mirror the events of a BOSH
+	// director deployed via `bosh create-env` (à la `bosh events`)
+	// as Kubernetes Events, on the resources they are about.
+	WatchEvents bool `json:"watchEvents,omitempty"`
+
+	// Rotate, if set, regenerates some of the credentials of a BOSH
+	// director deployed via `bosh create-env`, redeploys it, and then
+	// re-runs everything that was deployed (or uploaded, or
+	// configured) via the director with the new credentials.
+	Rotate *RotationSpec `json:"rotate,omitempty"`
+
+	// DeletionPolicy determines whether the deployment (or director)
+	// is torn down (Delete, the default), or left running (Orphan and
+	// Retain) when this BOSHDeployment is deleted.
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// Cancel, if set, cancels the director task that is currently
+	// running on behalf of this BOSHDeployment (if any), and keeps Gluon from
+	// starting another one until it is unset.  Directors deployed via
+	// create-env can't be cancelled.
+	Cancel bool `json:"cancel,omitempty"`
+}
+
+// UpgradeSpec defines the pre- and post-flight checks for in-place
+// upgrades of a BOSH director.
+type UpgradeSpec struct {
+	// ExpectVersion, if set, is the version (or version prefix) that
+	// the upgraded director has to report before the upgrade is
+	// considered a success.
+	ExpectVersion string `json:"expectVersion,omitempty"`
 }
 
+const (
+	StateBackendVolume = "volume"
+	StateBackendSecret = "secret"
+)
+
 // BOSHDeploymentStatus defines the observed state of BOSHDeployment
 type BOSHDeploymentStatus struct {
 	Ready bool   `json:"ready"`
 	State string `json:"state"`
+
+	// Ref is the ref of the deployment repository that was last
+	// successfully deployed.
+	Ref string `json:"ref,omitempty"`
+
+	// Upgrade tracks the most recent in-place upgrade of a BOSH
+	// director deployed via `bosh create-env`.
+	Upgrade *UpgradeStatus `json:"upgrade,omitempty"`
+
+	// Rotation tracks the most recent credential rotation of a BOSH
+	// director deployed via `bosh create-env`.
+	Rotation *RotationStatus `json:"rotation,omitempty"`
+
+	// Inventory summarizes what is on the BOSH director, for
+	// directors deployed via `bosh create-env`.
+	Inventory *DirectorInventory `json:"inventory,omitempty"`
+
+	// Events tracks the mirroring of director events, for directors
+	// deployed via `bosh create-env` with spec.watchEvents set.
+	Events *EventsStatus `json:"events,omitempty"`
+
+	// LastTask is the most recent director task run against this
+	// deployment, for deployments made via a BOSH director.
+	LastTask *InventoryTask `json:"lastTask,omitempty"`
+
+	// CurrentTask follows the director task started by the most
+	// recent Job, as it runs.
+	CurrentTask *TaskStatus `json:"currentTask,omitempty"`
+
+	Conditions Conditions `json:"conditions,omitempty"`
+}
+
+// UpgradeStatus defines the observed state of a director upgrade
+type UpgradeStatus struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	State string `json:"state"`
 }
 
+const (
+	// RefAnnotation records which ref of the deployment repository
+	// a deploy (or upgrade) Job is deploying.
+	RefAnnotation = "gluon.starkandwayne.com/ref"
+)
+
 // +kubebuilder:object:root=true
 
 // BOSHDeployment is the Schema for the boshdeployments API
 // +kubebuilder:resource:path=boshdeployments,scope=Namespaced,shortName=bosh
+// +kubebuilder:printcolumn:name="Ready",type="boolean",JSONPath=".status.ready"
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="Task",type="string",JSONPath=".status.currentTask.summary"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 type BOSHDeployment struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -107,18 +211,76 @@ func (bd *BOSHDeployment) StateConfigMapName() string {
 	return fmt.Sprintf("%s-state", bd.Name)
 }
 
+// StateSecretName is the name of the Secret that the create-env vars-store
+// (creds.yml) gets saved to, alongside the state ConfigMap.
+func (bd *BOSHDeployment) StateSecretName() string {
+	return fmt.Sprintf("%s-state", bd.Name)
+}
+
+// StateKeySecretName is the name of the Secret that holds the key that
+// the vars-store (creds.yml) is encrypted with, wherever it is saved.
+func (bd *BOSHDeployment) StateKeySecretName() string {
+	return fmt.Sprintf("%s-state-key", bd.Name)
+}
+
+// StateLockName is the name of the Lease that guards the create-env state
+// of a BOSH director, so that only one Job can touch it at a time.
+func (bd *BOSHDeployment) StateLockName() string {
+	return fmt.Sprintf("%s-state", bd.Name)
+}
+
+// StateSnapshotSecretName is the name of the Secret that the state files
+// of a BOSH director get copied to, just before it is upgraded.
+func (bd *BOSHDeployment) StateSnapshotSecretName() string {
+	return fmt.Sprintf("%s-state-snapshot", bd.Name)
+}
+
 func (bd *BOSHDeployment) SecretsName() string {
 	return fmt.Sprintf("%s-secrets", bd.Name)
 }
 
+// ViaDirector returns true if this BOSHDeployment is deployed by way of
+// another BOSH director (namespaced or cluster-wide), and false if it is
+// itself a BOSH director, deployed via `bosh create-env`.
+func (bd *BOSHDeployment) ViaDirector() bool {
+	return bd.Spec.Director != "" || bd.Spec.ClusterDirector != ""
+}
+
+// DirectorSecretsName returns the name of the Secret that holds the
+// credentials for the director this BOSHDeployment is deployed via.
+func (bd *BOSHDeployment) DirectorSecretsName() string {
+	if bd.Spec.ClusterDirector != "" {
+		return ClusterDirectorSecretsName(bd.Spec.ClusterDirector)
+	}
+	return fmt.Sprintf("%s-secrets", bd.Spec.Director)
+}
+
+func (bd *BOSHDeployment) DirectorLabels() map[string]string {
+	return map[string]string{
+		DirectorLabel: bd.Name,
+	}
+}
+
+func (bd *BOSHDeployment) MaxConcurrent(operation string) int {
+	return bd.Spec.Concurrency.Limit(operation)
+}
+
 func (bd *BOSHDeployment) JobName(verb string) string {
-	if bd.Spec.Director != "" {
+	if bd.Spec.ClusterDirector != "" {
+		return fmt.Sprintf("%s-%s-via-%s", verb, bd.Name, bd.Spec.ClusterDirector)
+	} else if bd.Spec.Director != "" {
 		return fmt.Sprintf("%s-%s-via-%s", verb, bd.Name, bd.Spec.Director)
 	} else {
 		return fmt.Sprintf("%s-%s-bosh", verb, bd.Name)
 	}
 }
 
+// UsesStateVolume returns true if this BOSHDeployment is a BOSH director
+// that keeps its create-env state on a persistent volume.
+func (bd *BOSHDeployment) UsesStateVolume() bool {
+	return !bd.ViaDirector() && bd.Spec.StateBackend != StateBackendSecret
+}
+
 func (bd *BOSHDeployment) StateVolume() *corev1.PersistentVolumeClaim {
 	mode := corev1.PersistentVolumeFilesystem
 	return &corev1.PersistentVolumeClaim{
@@ -148,8 +310,40 @@ func (bd *BOSHDeployment) TeardownJob() *batchv1.Job {
 	return bd.job("teardown")
 }
 
-func (bd *BOSHDeployment) job(verb string) *batchv1.Job {
-	vars := []corev1.EnvVar{
+// UpgradeJobName returns the name of the Job that upgrades a BOSH director
+// to the currently specified ref; each new ref gets its own Job.
+func (bd *BOSHDeployment) UpgradeJobName() string {
+	return fmt.Sprintf("%s-%s", bd.JobName("upgrade"), Revision(bd.Spec.Ref))
+}
+
+func (bd *BOSHDeployment) UpgradeJob() *batchv1.Job {
+	job := bd.job("upgrade")
+	job.Name = bd.UpgradeJobName()
+
+	expect := ""
+	if bd.Spec.Upgrade != nil {
+		expect = bd.Spec.Upgrade.ExpectVersion
+	}
+	job.Spec.Template.Spec.Containers[0].Env = append(job.Spec.Template.Spec.Containers[0].Env,
+		corev1.EnvVar{
+			Name:  "UPGRADE_FROM_REF",
+			Value: bd.Status.Ref,
+		},
+		corev1.EnvVar{
+			Name:  "EXPECTED_DIRECTOR_VERSION",
+			Value: expect,
+		},
+		corev1.EnvVar{
+			Name:  "STATE_SNAPSHOT_SECRET",
+			Value: bd.StateSnapshotSecretName(),
+		})
+	return job
+}
+
+// stateEnv returns the environment variables that tell the Gluon apparatus
+// scripts where the state files for this BOSHDeployment are to be found.
+func (bd *BOSHDeployment) stateEnv() []corev1.EnvVar {
+	return []corev1.EnvVar{
 		corev1.EnvVar{
 			Name: "POD_NAMESPACE",
 			ValueFrom: &corev1.EnvVarSource{
@@ -159,18 +353,6 @@ func (bd *BOSHDeployment) job(verb string) *batchv1.Job {
 				},
 			},
 		},
-		corev1.EnvVar{
-			Name:  "UPSTREAM_REPO",
-			Value: bd.Spec.Repo,
-		},
-		corev1.EnvVar{
-			Name:  "UPSTREAM_REF",
-			Value: bd.Spec.Ref,
-		},
-		corev1.EnvVar{
-			Name:  "UPSTREAM_ENTRYPOINT",
-			Value: bd.Spec.Entrypoint,
-		},
 		corev1.EnvVar{
 			Name:  "CREDS_SECRET_NAME",
 			Value: bd.SecretsName(),
@@ -179,14 +361,56 @@ func (bd *BOSHDeployment) job(verb string) *batchv1.Job {
 			Name:  "CREDS_STATE_FILE_CONFIG_MAP",
 			Value: bd.StateConfigMapName(),
 		},
+		corev1.EnvVar{
+			Name:  "CREDS_VARS_STORE_SECRET",
+			Value: bd.StateSecretName(),
+		},
+		corev1.EnvVar{
+			Name:  "STATE_BACKEND",
+			Value: bd.Spec.StateBackend,
+		},
+		bd.stateKeyEnv(),
 		corev1.EnvVar{
 			Name:  "GLUON_director_name", // FIXME
 			Value: bd.Name,
 		},
 	}
+}
+
+// stateKeyEnv returns the environment variable that hands the state key
+// to the apparatus scripts (see state-crypt).
+func (bd *BOSHDeployment) stateKeyEnv() corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: "STATE_KEY",
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: bd.StateKeySecretName(),
+				},
+				Key: "key",
+			},
+		},
+	}
+}
+
+func (bd *BOSHDeployment) job(verb string) *batchv1.Job {
+	vars := append(bd.stateEnv(),
+		jobNameEnv(),
+		corev1.EnvVar{
+			Name:  "UPSTREAM_REPO",
+			Value: bd.Spec.Repo,
+		},
+		corev1.EnvVar{
+			Name:  "UPSTREAM_REF",
+			Value: bd.Spec.Ref,
+		},
+		corev1.EnvVar{
+			Name:  "UPSTREAM_ENTRYPOINT",
+			Value: bd.Spec.Entrypoint,
+		})
 
-	if bd.Spec.Director != "" {
-		secretName := fmt.Sprintf("%s-secrets", bd.Spec.Director)
+	if bd.ViaDirector() {
+		secretName := bd.DirectorSecretsName()
 
 		vars = append(vars, corev1.EnvVar{
 			Name: "BOSH_ENVIRONMENT",
@@ -255,7 +479,47 @@ func (bd *BOSHDeployment) job(verb string) *batchv1.Job {
 
 	volumes := []corev1.Volume{}
 	mounts := []corev1.VolumeMount{}
-	if bd.Spec.Director == "" {
+	inits := []corev1.Container{}
+	if !bd.ViaDirector() && !bd.UsesStateVolume() {
+		volumes = append(volumes, corev1.Volume{
+			Name: "state",
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		})
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      "state",
+			MountPath: "/bosh/state",
+		})
+
+		// load the state files out of the state Secret; the main
+		// container writes them back (or tries to) when it's done.
+		inits = append(inits, corev1.Container{
+			Name:            "load-state",
+			Image:           GluonImage,
+			ImagePullPolicy: GluonPullPolicy,
+			Command:         []string{"secret-state", "load"},
+			VolumeMounts:    mounts,
+			Env: []corev1.EnvVar{
+				corev1.EnvVar{
+					Name: "POD_NAMESPACE",
+					ValueFrom: &corev1.EnvVarSource{
+						FieldRef: &corev1.ObjectFieldSelector{
+							APIVersion: "v1",
+							FieldPath:  "metadata.namespace",
+						},
+					},
+				},
+				corev1.EnvVar{
+					Name:  "CREDS_VARS_STORE_SECRET",
+					Value: bd.StateSecretName(),
+				},
+				bd.stateKeyEnv(),
+			},
+		})
+
+	} else if !bd.ViaDirector() {
+		optional := true
 		volumes = append(volumes, corev1.Volume{
 			Name: "state",
 			VolumeSource: corev1.VolumeSource{
@@ -264,10 +528,77 @@ func (bd *BOSHDeployment) job(verb string) *batchv1.Job {
 				},
 			},
 		})
+		volumes = append(volumes, corev1.Volume{
+			Name: "saved-state",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: bd.StateConfigMapName(),
+					},
+					Optional: &optional,
+				},
+			},
+		})
+		volumes = append(volumes, corev1.Volume{
+			Name: "saved-creds",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: bd.StateSecretName(),
+					Optional:   &optional,
+				},
+			},
+		})
 		mounts = append(mounts, corev1.VolumeMount{
 			Name:      "state",
 			MountPath: "/bosh/state",
 		})
+
+		// before we create-env / delete-env anything, restore any
+		// state files that are missing from the state volume, using
+		// the copies saved off by previous deploys.
+		inits = append(inits, corev1.Container{
+			Name:            "rehydrate",
+			Image:           GluonImage,
+			ImagePullPolicy: GluonPullPolicy,
+			Command:         []string{"rehydrate"},
+			Env:             []corev1.EnvVar{bd.stateKeyEnv()},
+			VolumeMounts: []corev1.VolumeMount{
+				corev1.VolumeMount{
+					Name:      "state",
+					MountPath: "/bosh/state",
+				},
+				corev1.VolumeMount{
+					Name:      "saved-state",
+					MountPath: "/bosh/saved/state",
+					ReadOnly:  true,
+				},
+				corev1.VolumeMount{
+					Name:      "saved-creds",
+					MountPath: "/bosh/saved/creds",
+					ReadOnly:  true,
+				},
+			},
+		})
+	}
+
+	// point the director's health monitor back at us, if asked
+	if !bd.ViaDirector() && bd.Spec.ForwardAlerts && verb != "teardown" {
+		volumes = append(volumes, corev1.Volume{
+			Name: "alerts",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: bd.AlertsName(),
+					},
+				},
+			},
+		})
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      "alerts",
+			MountPath: "/bosh/alerts",
+			ReadOnly:  true,
+		})
+		command = append(command, "-o", "/bosh/alerts/"+AlertsOpsFile)
 	}
 
 	// create the Job resource, in all of its glory
@@ -276,6 +607,10 @@ func (bd *BOSHDeployment) job(verb string) *batchv1.Job {
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: bd.Namespace,
 			Name:      bd.JobName(verb),
+			Labels:    directorLabels(bd.Spec.Director, bd.Spec.ClusterDirector, OperationDeployment),
+			Annotations: map[string]string{
+				RefAnnotation: bd.Spec.Ref,
+			},
 		},
 		Spec: batchv1.JobSpec{
 			Parallelism:  &one,
@@ -284,8 +619,9 @@ func (bd *BOSHDeployment) job(verb string) *batchv1.Job {
 			//TTLSecondsAfterFinished
 			Template: corev1.PodTemplateSpec{
 				Spec: corev1.PodSpec{
-					RestartPolicy: corev1.RestartPolicyNever,
-					Volumes:       volumes,
+					RestartPolicy:  corev1.RestartPolicyNever,
+					Volumes:        volumes,
+					InitContainers: inits,
 					Containers: []corev1.Container{
 						corev1.Container{
 							Name:            "deploy",
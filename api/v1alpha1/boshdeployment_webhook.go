@@ -0,0 +1,100 @@
+/*
+Gluon - BOSH / CF Orchestration via Kuberenetes API(s)
+
+Copyright (c) 2020 James Hunt
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to
+deal in the Software without restriction, including without limitation the
+rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+sell copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software..
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+IN THE SOFTWARE.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+func (r *BOSHDeployment) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:verbs=create;update;delete,path=/validate-gluon-starkandwayne-com-v1alpha1-boshdeployment,mutating=false,failurePolicy=fail,groups=gluon.starkandwayne.com,resources=boshdeployments,versions=v1alpha1,name=vboshdeployment.gluon.starkandwayne.com
+
+var _ webhook.Validator = &BOSHDeployment{}
+
+// ValidateCreate implements webhook.Validator
+func (r *BOSHDeployment) ValidateCreate() error {
+	return r.validateCancel(nil)
+}
+
+// ValidateUpdate implements webhook.Validator
+func (r *BOSHDeployment) ValidateUpdate(old runtime.Object) error {
+	if !r.DeletionTimestamp.IsZero() {
+		// let go of it, whatever it looks like
+		return nil
+	}
+	previous, ok := old.(*BOSHDeployment)
+	if !ok {
+		return nil
+	}
+	if err := r.validateCancel(previous); err != nil {
+		return err
+	}
+	return r.validateStateBackend(previous)
+}
+
+// validateCancel keeps anyone from cancelling a create-env director.
+// There is no director task to cancel, and killing create-env halfway
+// through would leave its state in whatever shape it was in at the time.
+// (Directors that were already cancelled can stay that way.)
+func (r *BOSHDeployment) validateCancel(old *BOSHDeployment) error {
+	if r.ViaDirector() || !r.Spec.Cancel || (old != nil && old.Spec.Cancel) {
+		return nil
+	}
+	return fmt.Errorf("BOSHDeployment %s/%s is deployed via create-env, which can't be cancelled", r.Namespace, r.Name)
+}
+
+// validateStateBackend keeps a create-env director on the state backend
+// it was first deployed with.  Neither backend knows to look for state
+// that the other one saved, so switching would have create-env stand up
+// a second, brand new director.
+func (r *BOSHDeployment) validateStateBackend(old *BOSHDeployment) error {
+	if r.ViaDirector() || old.Status.State == "" {
+		return nil
+	}
+	backend := func(bd *BOSHDeployment) string {
+		if bd.Spec.StateBackend == "" {
+			return StateBackendVolume
+		}
+		return bd.Spec.StateBackend
+	}
+	if backend(r) != backend(old) {
+		return fmt.Errorf("BOSHDeployment %s/%s keeps its state in a %s; it cannot be switched to a %s once deployed", r.Namespace, r.Name, backend(old), backend(r))
+	}
+	return nil
+}
+
+// ValidateDelete implements webhook.Validator
+func (r *BOSHDeployment) ValidateDelete() error {
+	return validateDelete("BOSHDeployment", r)
+}
@@ -0,0 +1,256 @@
+/*
+Gluon - BOSH / CF Orchestration via Kuberenetes API(s)
+
+Copyright (c) 2020 James Hunt
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to
+deal in the Software without restriction, including without limitation the
+rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+sell copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software..
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+IN THE SOFTWARE.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ObjectStorageSpec defines where director backups are kept, in any
+// S3-compatible object store (AWS S3, MinIO, etc.)
+type ObjectStorageSpec struct {
+	// Endpoint is the URL of the S3-compatible API,
+	// i.e. https://s3.amazonaws.com or http://minio.example:9000
+	Endpoint string `json:"endpoint"`
+	Bucket   string `json:"bucket"`
+	Prefix   string `json:"prefix,omitempty"`
+	Region   string `json:"region,omitempty"`
+
+	// Secret names a Secret (in the same namespace) with the
+	// `accessKey` and `secretKey` for the object store.
+	Secret string `json:"secret"`
+
+	SkipSSLValidation bool `json:"skipSSLValidation,omitempty"`
+}
+
+// BOSHDirectorBackupSpec defines the desired state of BOSHDirectorBackup
+type BOSHDirectorBackupSpec struct {
+	// Director is the name of the BOSHDeployment (deployed via
+	// `bosh create-env`) whose state is to be backed up.
+	Director string `json:"director"`
+
+	// Schedule is a cron-style schedule for taking backups.
+	// If left blank, a single backup is taken, right away.
+	Schedule string `json:"schedule,omitempty"`
+
+	Storage ObjectStorageSpec `json:"storage"`
+
+	// BBR, if set, includes a BOSH Backup and Restore (bbr)
+	// backup of the director's databases and blobstore.
+	BBR bool `json:"bbr,omitempty"`
+}
+
+// BOSHDirectorBackupStatus defines the observed state of BOSHDirectorBackup
+type BOSHDirectorBackupStatus struct {
+	Ready bool   `json:"ready"`
+	State string `json:"state"`
+
+	// LastBackupTime is when the most recent successful backup
+	// finished.
+	LastBackupTime *metav1.Time `json:"lastBackupTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// BOSHDirectorBackup is the Schema for the boshdirectorbackups API
+// +kubebuilder:resource:path=boshdirectorbackups,scope=Namespaced,shortName=bdb
+type BOSHDirectorBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   BOSHDirectorBackupSpec   `json:"spec,omitempty"`
+	Status BOSHDirectorBackupStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// BOSHDirectorBackupList contains a list of BOSHDirectorBackup
+type BOSHDirectorBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BOSHDirectorBackup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&BOSHDirectorBackup{}, &BOSHDirectorBackupList{})
+}
+
+func (b *BOSHDirectorBackup) JobName() string {
+	return fmt.Sprintf("backup-%s", b.Name)
+}
+
+// CronJob returns a CronJob that takes backups on the schedule.
+func (b *BOSHDirectorBackup) CronJob(director *BOSHDeployment) *batchv1beta1.CronJob {
+	job := b.Job(director)
+	return &batchv1beta1.CronJob{
+		ObjectMeta: job.ObjectMeta,
+		Spec: batchv1beta1.CronJobSpec{
+			Schedule:          b.Spec.Schedule,
+			ConcurrencyPolicy: batchv1beta1.ForbidConcurrent,
+			JobTemplate: batchv1beta1.JobTemplateSpec{
+				Spec: job.Spec,
+			},
+		},
+	}
+}
+
+// Job returns a Job that takes a single backup.  Rather than fight over
+// the (ReadWriteOnce) state volume, backups work from the copies of the
+// state files that each create-env saves off to its ConfigMap / Secret.
+func (b *BOSHDirectorBackup) Job(director *BOSHDeployment) *batchv1.Job {
+	optional := true
+	env := append(b.Spec.Storage.env(), director.stateEnv()...)
+	env = append(env,
+		corev1.EnvVar{
+			Name:  "BACKUP_BBR",
+			Value: fmt.Sprintf("%t", b.Spec.BBR),
+		},
+		corev1.EnvVar{
+			Name: "DIRECTOR_ENDPOINT",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: director.SecretsName(),
+					},
+					Key:      "endpoint",
+					Optional: &optional,
+				},
+			},
+		})
+
+	var one int32 = 1
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: b.Namespace,
+			Name:      b.JobName(),
+		},
+		Spec: batchv1.JobSpec{
+			Parallelism:  &one,
+			Completions:  &one,
+			BackoffLimit: &one,
+			//TTLSecondsAfterFinished
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Volumes: []corev1.Volume{
+						corev1.Volume{
+							Name: "saved-state",
+							VolumeSource: corev1.VolumeSource{
+								ConfigMap: &corev1.ConfigMapVolumeSource{
+									LocalObjectReference: corev1.LocalObjectReference{
+										Name: director.StateConfigMapName(),
+									},
+									Optional: &optional,
+								},
+							},
+						},
+						corev1.Volume{
+							Name: "saved-creds",
+							VolumeSource: corev1.VolumeSource{
+								Secret: &corev1.SecretVolumeSource{
+									SecretName: director.StateSecretName(),
+									Optional:   &optional,
+								},
+							},
+						},
+					},
+					Containers: []corev1.Container{
+						corev1.Container{
+							Name:            "backup",
+							Image:           GluonImage,
+							ImagePullPolicy: GluonPullPolicy,
+							Command:         []string{"backup"},
+							Env:             env,
+							VolumeMounts: []corev1.VolumeMount{
+								corev1.VolumeMount{
+									Name:      "saved-state",
+									MountPath: "/bosh/saved/state",
+									ReadOnly:  true,
+								},
+								corev1.VolumeMount{
+									Name:      "saved-creds",
+									MountPath: "/bosh/saved/creds",
+									ReadOnly:  true,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (s ObjectStorageSpec) env() []corev1.EnvVar {
+	return []corev1.EnvVar{
+		corev1.EnvVar{
+			Name:  "BACKUP_ENDPOINT",
+			Value: s.Endpoint,
+		},
+		corev1.EnvVar{
+			Name:  "BACKUP_BUCKET",
+			Value: s.Bucket,
+		},
+		corev1.EnvVar{
+			Name:  "BACKUP_PREFIX",
+			Value: s.Prefix,
+		},
+		corev1.EnvVar{
+			Name:  "BACKUP_REGION",
+			Value: s.Region,
+		},
+		corev1.EnvVar{
+			Name:  "BACKUP_SKIP_SSL_VALIDATION",
+			Value: fmt.Sprintf("%t", s.SkipSSLValidation),
+		},
+		corev1.EnvVar{
+			Name: "BACKUP_ACCESS_KEY",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: s.Secret,
+					},
+					Key: "accessKey",
+				},
+			},
+		},
+		corev1.EnvVar{
+			Name: "BACKUP_SECRET_KEY",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: s.Secret,
+					},
+					Key: "secretKey",
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Gluon - BOSH / CF Orchestration via Kuberenetes API(s)
+
+Copyright (c) 2020 James Hunt
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to
+deal in the Software without restriction, including without limitation the
+rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+sell copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software..
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+IN THE SOFTWARE.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BOSHDirectorRestoreSpec defines the desired state of BOSHDirectorRestore
+type BOSHDirectorRestoreSpec struct {
+	// Director is the name of the BOSHDeployment (deployed via
+	// `bosh create-env`) whose state is to be restored.
+	Director string `json:"director"`
+
+	Storage ObjectStorageSpec `json:"storage"`
+
+	// Backup is the key (under the storage prefix) of the backup
+	// archive to restore.  If left blank, the most recent backup of
+	// the director is restored.
+	Backup string `json:"backup,omitempty"`
+
+	// BBR, if set, also restores the BOSH Backup and Restore (bbr)
+	// backup of the director's databases and blobstore, if the
+	// backup archive has one.
+	BBR bool `json:"bbr,omitempty"`
+}
+
+// BOSHDirectorRestoreStatus defines the observed state of BOSHDirectorRestore
+type BOSHDirectorRestoreStatus struct {
+	Ready bool   `json:"ready"`
+	State string `json:"state"`
+}
+
+// +kubebuilder:object:root=true
+
+// BOSHDirectorRestore is the Schema for the boshdirectorrestores API
+// +kubebuilder:resource:path=boshdirectorrestores,scope=Namespaced,shortName=bdr
+type BOSHDirectorRestore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   BOSHDirectorRestoreSpec   `json:"spec,omitempty"`
+	Status BOSHDirectorRestoreStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// BOSHDirectorRestoreList contains a list of BOSHDirectorRestore
+type BOSHDirectorRestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BOSHDirectorRestore `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&BOSHDirectorRestore{}, &BOSHDirectorRestoreList{})
+}
+
+func (r *BOSHDirectorRestore) JobName() string {
+	return fmt.Sprintf("restore-%s", r.Name)
+}
+
+// Job returns the Job that restores the director state.  It gets the same
+// state volume (or Secret) setup that create-env jobs do, so that both
+// the live state and the saved copies get restored.
+func (r *BOSHDirectorRestore) Job(director *BOSHDeployment) *batchv1.Job {
+	job := director.job("restore")
+	job.Name = r.JobName()
+	job.Namespace = r.Namespace
+	job.Labels = nil
+	job.Annotations = nil
+
+	container := &job.Spec.Template.Spec.Containers[0]
+	container.Name = "restore"
+	container.Command = []string{"restore"}
+	// (the director may not be up yet, and its Secret not yet written)
+	optional := true
+	container.Env = append(container.Env, r.Spec.Storage.env()...)
+	container.Env = append(container.Env,
+		corev1.EnvVar{
+			Name:  "BACKUP_KEY",
+			Value: r.Spec.Backup,
+		},
+		corev1.EnvVar{
+			Name:  "BACKUP_BBR",
+			Value: fmt.Sprintf("%t", r.Spec.BBR),
+		},
+		corev1.EnvVar{
+			Name: "DIRECTOR_ENDPOINT",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: director.SecretsName(),
+					},
+					Key:      "endpoint",
+					Optional: &optional,
+				},
+			},
+		})
+	return job
+}
@@ -0,0 +1,299 @@
+/*
+Gluon - BOSH / CF Orchestration via Kuberenetes API(s)
+
+Copyright (c) 2020 James Hunt
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to
+deal in the Software without restriction, including without limitation the
+rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+sell copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software..
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+IN THE SOFTWARE.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BOSHReleaseSpec defines the desired state of BOSHRelease
+type BOSHReleaseSpec struct {
+	Director        string `json:"director,omitempty"`
+	ClusterDirector string `json:"clusterDirector,omitempty"`
+
+	// The release can also be uploaded to several directors at once,
+	// named (or selected by label) here.
+	DirectorTargets `json:",inline"`
+
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+	URL     string `json:"url,omitempty"`
+	Fix     bool   `json:"fix,omitempty"`
+
+	// Digest is the checksum of the release tarball, given the same way
+	// as for stemcells: "sha256:4e9f...", or several checksums,
+	// separated by semicolons.
+	Digest string `json:"digest,omitempty"`
+
+	// SHA1 is the old name of Digest, still accepted (and read the same
+	// way) if Digest is not given.
+	//
+	// Deprecated: use Digest.
+	SHA1 string `json:"sha1,omitempty"`
+
+	// Instead of a tarball, a release can be built from its Git
+	// repository, via `bosh create-release`.
+	Git *ReleaseGitSpec `json:"git,omitempty"`
+
+	// Cache names a BOSHArtifactCache (in the same namespace) to fetch
+	// the release tarball through.
+	Cache string `json:"cache,omitempty"`
+
+	// DeletionPolicy determines what happens to the release on the
+	// director when this BOSHRelease is deleted.  Under the Delete
+	// policy (the default), it is deleted from the director, unless
+	// some deployment is still using it.
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// Cancel, if set, cancels the director task that is currently
+	// running on behalf of this BOSHRelease (if any), and keeps Gluon
+	// from starting another one until it is unset.
+	Cancel bool `json:"cancel,omitempty"`
+}
+
+// ReleaseGitSpec points at a BOSH release repository.
+type ReleaseGitSpec struct {
+	// Repository is the URL to clone, and Ref the branch, tag or
+	// commit to check out (the default branch, unless told otherwise).
+	Repository string `json:"repository"`
+	Ref        string `json:"ref,omitempty"`
+
+	// Manifest is the path (within the repository) to a final release
+	// manifest, i.e. releases/nats/nats-34.yml, to build that final
+	// release.  Without it, a dev release is built, named and
+	// versioned after the spec (if it says).
+	Manifest string `json:"manifest,omitempty"`
+}
+
+// BOSHReleaseStatus defines the observed state of BOSHRelease
+type BOSHReleaseStatus struct {
+	Ready bool   `json:"ready"`
+	State string `json:"state"`
+
+	// CurrentTask follows the director task started by the most
+	// recent Job, as it runs (on any one of the directors).
+	CurrentTask *TaskStatus `json:"currentTask,omitempty"`
+
+	// Directors tracks the upload to each of the targeted directors.
+	// The release is only Ready once it is on all of them.
+	Directors []ReleaseDirectorStatus `json:"directors,omitempty"`
+
+	// CacheURL is where the artifact cache named in the spec can be
+	// reached (once it is ready).
+	CacheURL string `json:"cacheURL,omitempty"`
+}
+
+// ReleaseDirectorStatus tracks the upload of a release to one director.
+type ReleaseDirectorStatus struct {
+	DirectorStatus `json:",inline"`
+
+	// Uploaded is the release that ended up on the director, as far
+	// as Gluon could tell.
+	Uploaded *UploadedRelease `json:"uploaded,omitempty"`
+
+	// Revision identifies the release that the current upload Job
+	// uploads (see BOSHRelease.Revision).
+	Revision string `json:"revision,omitempty"`
+}
+
+// UploadedRelease identifies a release on a BOSH director.
+type UploadedRelease struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+
+	// Digest is what the upload Job measured the tarball that it
+	// uploaded to be, i.e. "sha1:a1b2...;sha256:4e9f...", or (without
+	// an artifact cache) what the director checked it against.
+	Digest string `json:"digest,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// BOSHRelease is the Schema for the boshreleases API
+// +kubebuilder:resource:path=boshreleases,scope=Namespaced,shortName=release;brl
+// +kubebuilder:printcolumn:name="Ready",type="boolean",JSONPath=".status.ready"
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="Task",type="string",JSONPath=".status.currentTask.summary"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type BOSHRelease struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec         BOSHReleaseSpec   `json:"spec,omitempty"`
+	Dependencies DependencySpecs   `json:"dependencies,omitempty"`
+	Status       BOSHReleaseStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// BOSHReleaseList contains a list of BOSHRelease
+type BOSHReleaseList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BOSHRelease `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&BOSHRelease{}, &BOSHReleaseList{})
+}
+
+// Revision identifies the release to upload (its name and version, and
+// where it comes from), so that each change to it gets its own upload
+// Job.
+func (br *BOSHRelease) Revision() string {
+	if git := br.Spec.Git; git != nil {
+		return Revision(br.Spec.Name, br.Spec.Version, git.Repository, git.Ref, git.Manifest)
+	}
+	return Revision(br.Spec.Name, br.Spec.Version, br.Spec.URL, br.Digest())
+}
+
+func (br *BOSHRelease) JobName(director Director) string {
+	return br.RevisionJobName(director, br.Revision())
+}
+
+// RevisionJobName returns the name of the Job that uploads the given
+// revision of the release to director.  Jobs from before revisions
+// (with an empty revision) went without, and named cluster directors
+// the same way as namespaced ones.
+func (br *BOSHRelease) RevisionJobName(director Director, revision string) string {
+	if revision == "" {
+		return fmt.Sprintf("upload-release-%s-to-%s", br.Name, director.GetName())
+	}
+	return fmt.Sprintf("upload-release-%s-to-%s-%s", br.Name, jobTarget(director), revision)
+}
+
+// Digest returns the checksum(s) that the release tarball has to match.
+func (br *BOSHRelease) Digest() string {
+	return specDigest(br.Spec.Digest, br.Spec.SHA1)
+}
+
+func (br *BOSHRelease) DeleteJobName(director Director) string {
+	return fmt.Sprintf("delete-release-%s-from-%s", br.Name, jobTarget(director))
+}
+
+// DirectorStatus returns the status of the upload to the given director,
+// starting one if need be.
+func (br *BOSHRelease) DirectorStatus(director Director) *ReleaseDirectorStatus {
+	for i := range br.Status.Directors {
+		if br.Status.Directors[i].Is(director) {
+			return &br.Status.Directors[i]
+		}
+	}
+	br.Status.Directors = append(br.Status.Directors, ReleaseDirectorStatus{DirectorStatus: newDirectorStatus(director)})
+	return &br.Status.Directors[len(br.Status.Directors)-1]
+}
+
+// Targets returns true if the release is (or was) bound for the named
+// director, or cluster director.
+func (br *BOSHRelease) Targets(name, cluster string) bool {
+	if cluster != "" && br.Spec.ClusterDirector == cluster || cluster == "" && br.Spec.Director == name {
+		return true
+	}
+	for _, s := range br.Status.Directors {
+		if s.Director == name && s.ClusterDirector == cluster {
+			return true
+		}
+	}
+	return false
+}
+
+// DeleteJob returns a Job that removes the uploaded release from the
+// director.
+func (br *BOSHRelease) DeleteJob(director Director, uploaded *UploadedRelease) *batchv1.Job {
+	job := br.Job(director)
+	job.Name = br.DeleteJobName(director)
+	container := &job.Spec.Template.Spec.Containers[0]
+	container.Name = "delete-release"
+	container.Command = []string{"track-task", "bosh", "-n", "delete-release"}
+	if uploaded != nil {
+		container.Command = append(container.Command,
+			fmt.Sprintf("%s/%s", uploaded.Name, uploaded.Version))
+	}
+	return job
+}
+
+// Job returns a Job that uploads the release to the director, either from
+// its tarball (fetched, and checked, by the Job itself) or by building it
+// from its Git repository.
+func (br *BOSHRelease) Job(director Director) *batchv1.Job {
+	var command []string
+	if git := br.Spec.Git; git != nil {
+		// build-release stands the tarball in for the repository URL
+		command = []string{"track-task", "bosh", "upload-release", git.Repository}
+		if br.Spec.Fix {
+			command = append(command, "--fix")
+		}
+		manifest := git.Manifest
+		if manifest == "" {
+			manifest = "-"
+		}
+		command = append([]string{"build-release", git.Repository, git.Ref, manifest, br.Spec.Name, br.Spec.Version}, command...)
+
+	} else {
+		command = []string{"track-task", "bosh", "upload-release", br.Spec.URL}
+		if br.Spec.Name != "" {
+			command = append(command, "--name", br.Spec.Name)
+		}
+		if br.Spec.Version != "" {
+			command = append(command, "--version", br.Spec.Version)
+		}
+		if br.Spec.Fix {
+			command = append(command, "--fix")
+		}
+		command = uploadArtifact(br.Status.CacheURL, br.Digest(), br.Spec.URL, command)
+	}
+
+	var one int32 = 1
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: br.ObjectMeta.Namespace,
+			Name:      br.JobName(director),
+			Labels:    jobLabels(director, OperationRelease),
+		},
+		Spec: batchv1.JobSpec{
+			Parallelism:  &one,
+			Completions:  &one,
+			BackoffLimit: &one,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						corev1.Container{
+							Name:            "upload",
+							Image:           GluonImage,
+							ImagePullPolicy: GluonPullPolicy,
+							Command:         command,
+							Env:             append(taskEnv(), directorEnv(director.SecretsName())...),
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -26,6 +26,7 @@ package v1alpha1
 
 import (
 	"fmt"
+	"strings"
 
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -37,25 +38,137 @@ import (
 
 // BOSHStemcellSpec defines the desired state of BOSHStemcell
 type BOSHStemcellSpec struct {
-	Director string `json:"director"`
+	Director        string `json:"director,omitempty"`
+	ClusterDirector string `json:"clusterDirector,omitempty"`
+
+	// The stemcell can also be uploaded to several directors at once,
+	// named (or selected by label) here.
+	DirectorTargets `json:",inline"`
 
 	Name    string `json:"name,omitempty"`
 	Version string `json:"version,omitempty"`
-	URL     string `json:"url"`
-	SHA1    string `json:"sha1"`
+	URL     string `json:"url,omitempty"`
 	Fix     bool   `json:"fix,omitempty"`
+
+	// Digest is the checksum of the stemcell tarball: a SHA1 or SHA256,
+	// prefixed with its algorithm (i.e. "sha256:4e9f..."), or several
+	// of them, separated by semicolons ("sha1:a1b2...;sha256:4e9f...").
+	// All of them are checked before the stemcell is uploaded (by the
+	// upload Job, or without an artifact cache, by the director).
+	Digest string `json:"digest,omitempty"`
+
+	// SHA1 is the old name of Digest, still accepted (and read the same
+	// way) if Digest is not given.
+	//
+	// Deprecated: use Digest.
+	SHA1 string `json:"sha1,omitempty"`
+
+	// Instead of a URL and digest, a stemcell can be given by operating
+	// system (i.e. ubuntu-jammy) and IaaS (i.e. aws, or vsphere), and
+	// resolved against the bosh.io stemcell index (or a mirror of it).
+	// Version is then an exact version, a version line (i.e. "1.*"),
+	// or "latest" (the default); Light asks for a light stemcell.
+	OS    string `json:"os,omitempty"`
+	IaaS  string `json:"iaas,omitempty"`
+	Light bool   `json:"light,omitempty"`
+
+	// Cache names a BOSHArtifactCache (in the same namespace) to fetch
+	// the stemcell through, rather than having each director download
+	// it for itself.
+	Cache string `json:"cache,omitempty"`
+
+	// DeletionPolicy determines what happens to the stemcell on the
+	// director when this BOSHStemcell is deleted.  Under the Delete
+	// policy (the default), it is deleted from the director, unless
+	// some deployment is still using it.
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// Cancel, if set, cancels the director task that is currently
+	// running on behalf of this BOSHStemcell (if any), and keeps Gluon from
+	// starting another one until it is unset.
+	Cancel bool `json:"cancel,omitempty"`
 }
 
 // BOSHStemcellStatus defines the observed state of BOSHStemcell
 type BOSHStemcellStatus struct {
 	Ready bool   `json:"ready"`
 	State string `json:"state"`
+
+	// CurrentTask follows the director task started by the most
+	// recent Job, as it runs (on any one of the directors).
+	CurrentTask *TaskStatus `json:"currentTask,omitempty"`
+
+	// Directors tracks the upload to each of the targeted directors.
+	// The stemcell is only Ready once it is on all of them.
+	Directors []StemcellDirectorStatus `json:"directors,omitempty"`
+
+	// Resolved is what the operating system, version and IaaS in the
+	// spec resolved to, in the stemcell index.
+	Resolved *ResolvedStemcell `json:"resolved,omitempty"`
+
+	// Conditions reports drift, i.e. the stemcell disappearing from a
+	// director after it was uploaded.
+	Conditions Conditions `json:"conditions,omitempty"`
+
+	// CacheURL is where the artifact cache named in the spec can be
+	// reached (once it is ready).
+	CacheURL string `json:"cacheURL,omitempty"`
+}
+
+// StemcellDirectorStatus tracks the upload of a stemcell to one director.
+type StemcellDirectorStatus struct {
+	DirectorStatus `json:",inline"`
+
+	// Uploaded is the stemcell that ended up on the director, as far
+	// as Gluon could tell.
+	Uploaded *UploadedStemcell `json:"uploaded,omitempty"`
+
+	// VerifiedAt is when Gluon last saw the uploaded stemcell on the
+	// director.
+	VerifiedAt *metav1.Time `json:"verifiedAt,omitempty"`
+
+	// Missing is set when the stemcell went missing from the director,
+	// until it has been uploaded (and seen) again.
+	Missing bool `json:"missing,omitempty"`
+
+	// Revision identifies the stemcell that the current upload Job
+	// uploads (see BOSHStemcell.Revision).
+	Revision string `json:"revision,omitempty"`
+}
+
+// ResolvedStemcell is a concrete stemcell, found in a stemcell index.
+type ResolvedStemcell struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	URL     string `json:"url"`
+	SHA1    string `json:"sha1"`
+	SHA256  string `json:"sha256,omitempty"`
+
+	// Index is the stemcell index it was found in, and Query what was
+	// asked of it (so that changes to the spec can be noticed).
+	Index string `json:"index,omitempty"`
+	Query string `json:"query,omitempty"`
+}
+
+// UploadedStemcell identifies a stemcell on a BOSH director.
+type UploadedStemcell struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+
+	// Digest is what the upload Job measured the tarball that it
+	// uploaded to be, i.e. "sha1:a1b2...;sha256:4e9f...", or (without
+	// an artifact cache) what the director checked it against.
+	Digest string `json:"digest,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 
 // BOSHStemcell is the Schema for the boshstemcells API
 // +kubebuilder:resource:path=boshstemcells,scope=Namespaced,shortName=stemcell;bsc
+// +kubebuilder:printcolumn:name="Ready",type="boolean",JSONPath=".status.ready"
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="Task",type="string",JSONPath=".status.currentTask.summary"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 type BOSHStemcell struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -78,37 +191,156 @@ func init() {
 	SchemeBuilder.Register(&BOSHStemcell{}, &BOSHStemcellList{})
 }
 
-func (bs *BOSHStemcell) JobName(director *BOSHDeployment) string {
-	return fmt.Sprintf("upload-%s-to-%s", bs.Name, director.Name)
+// Revision identifies the stemcell to upload (where it comes from, what
+// it is, and its checksums), so that each change to it, including a new
+// version turning up in the stemcell index, gets its own upload Job.
+func (bs *BOSHStemcell) Revision() string {
+	url, name, version := bs.source()
+	return Revision(url, name, version, bs.Digest())
+}
+
+func (bs *BOSHStemcell) JobName(director Director) string {
+	return bs.RevisionJobName(director, bs.Revision())
+}
+
+// RevisionJobName returns the name of the Job that uploads the given
+// revision of the stemcell to director.  Jobs from before revisions
+// (with an empty revision) went without, and named cluster directors
+// the same way as namespaced ones.
+func (bs *BOSHStemcell) RevisionJobName(director Director, revision string) string {
+	if revision == "" {
+		return fmt.Sprintf("upload-%s-to-%s", bs.Name, director.GetName())
+	}
+	return fmt.Sprintf("upload-%s-to-%s-%s", bs.Name, jobTarget(director), revision)
+}
+
+// source returns the URL, name and version of the stemcell to upload,
+// as given in the spec, or as resolved from the stemcell index.
+func (bs *BOSHStemcell) source() (string, string, string) {
+	if bs.FromIndex() {
+		if r := bs.Status.Resolved; r != nil {
+			return r.URL, r.Name, r.Version
+		}
+		return "", "", ""
+	}
+	return bs.Spec.URL, bs.Spec.Name, bs.Spec.Version
+}
+
+// DirectorStatus returns the status of the upload to the given director,
+// starting one if need be.
+func (bs *BOSHStemcell) DirectorStatus(director Director) *StemcellDirectorStatus {
+	for i := range bs.Status.Directors {
+		if bs.Status.Directors[i].Is(director) {
+			return &bs.Status.Directors[i]
+		}
+	}
+	bs.Status.Directors = append(bs.Status.Directors, StemcellDirectorStatus{DirectorStatus: newDirectorStatus(director)})
+	return &bs.Status.Directors[len(bs.Status.Directors)-1]
+}
+
+// Targets returns true if the stemcell is (or was) bound for the named
+// director, or cluster director.
+func (bs *BOSHStemcell) Targets(name, cluster string) bool {
+	if cluster != "" && bs.Spec.ClusterDirector == cluster || cluster == "" && bs.Spec.Director == name {
+		return true
+	}
+	for _, s := range bs.Status.Directors {
+		if s.Director == name && s.ClusterDirector == cluster {
+			return true
+		}
+	}
+	return false
+}
+
+// FromIndex returns true if the stemcell has to be resolved against a
+// stemcell index, rather than being given by URL.
+func (bs *BOSHStemcell) FromIndex() bool {
+	return bs.Spec.OS != ""
+}
+
+// IndexQuery sums up what is being asked of the stemcell index.
+func (bs *BOSHStemcell) IndexQuery() string {
+	version := bs.Spec.Version
+	if version == "" {
+		version = "latest"
+	}
+	q := fmt.Sprintf("%s/%s on %s", bs.Spec.OS, version, bs.Spec.IaaS)
+	if bs.Spec.Light {
+		q += " (light)"
+	}
+	return q
+}
+
+// Digest returns the checksum(s) that the stemcell tarball has to match.
+func (bs *BOSHStemcell) Digest() string {
+	if bs.FromIndex() {
+		r := bs.Status.Resolved
+		if r == nil {
+			return ""
+		}
+		// (the index may not have every kind of checksum)
+		digests := []string{}
+		if r.SHA256 != "" {
+			digests = append(digests, "sha256:"+r.SHA256)
+		}
+		if r.SHA1 != "" {
+			digests = append(digests, "sha1:"+r.SHA1)
+		}
+		return strings.Join(digests, ";")
+	}
+	return specDigest(bs.Spec.Digest, bs.Spec.SHA1)
+}
+
+func (bs *BOSHStemcell) DeleteJobName(director Director) string {
+	return fmt.Sprintf("delete-%s-from-%s", bs.Name, jobTarget(director))
+}
+
+// DeleteJob returns a Job that removes the uploaded stemcell from the
+// director.
+func (bs *BOSHStemcell) DeleteJob(director Director, uploaded *UploadedStemcell) *batchv1.Job {
+	job := bs.Job(director)
+	job.Name = bs.DeleteJobName(director)
+	container := &job.Spec.Template.Spec.Containers[0]
+	container.Name = "delete-stemcell"
+	container.Command = []string{"track-task", "bosh", "-n", "delete-stemcell"}
+	if uploaded != nil {
+		container.Command = append(container.Command,
+			fmt.Sprintf("%s/%s", uploaded.Name, uploaded.Version))
+	}
+	return job
 }
 
-func (bs *BOSHStemcell) Job(director *BOSHDeployment) *batchv1.Job {
+func (bs *BOSHStemcell) Job(director Director) *batchv1.Job {
 	secret := director.SecretsName()
 
+	url, name, version := bs.source()
 	command := []string{
+		"track-task",
 		"bosh",
 		"upload-stemcell",
-		bs.Spec.URL,
-		"--sha1",
-		bs.Spec.SHA1,
+		url,
 	}
-	if bs.Spec.Name != "" {
+	if name != "" {
 		command = append(command, "--name")
-		command = append(command, bs.Spec.Name)
+		command = append(command, name)
 	}
-	if bs.Spec.Version != "" {
+	if version != "" {
 		command = append(command, "--version")
-		command = append(command, bs.Spec.Version)
+		command = append(command, version)
 	}
 	if bs.Spec.Fix {
 		command = append(command, "--fix")
 	}
+	// with an artifact cache, the Job fetches (and checks) the tarball
+	// itself, through the cache, and uploads its local copy
+	command = uploadArtifact(bs.Status.CacheURL, bs.Digest(), url, command)
 
 	var one int32 = 1
 	return &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: bs.ObjectMeta.Namespace,
 			Name:      bs.JobName(director),
+			Labels:    jobLabels(director, OperationStemcell),
 		},
 		Spec: batchv1.JobSpec{
 			Parallelism:  &one,
@@ -124,52 +356,7 @@ func (bs *BOSHStemcell) Job(director *BOSHDeployment) *batchv1.Job {
 							Image:           GluonImage,
 							ImagePullPolicy: GluonPullPolicy,
 							Command:         command,
-							Env: []corev1.EnvVar{
-								corev1.EnvVar{
-									Name: "BOSH_ENVIRONMENT",
-									ValueFrom: &corev1.EnvVarSource{
-										SecretKeyRef: &corev1.SecretKeySelector{
-											LocalObjectReference: corev1.LocalObjectReference{
-												Name: secret,
-											},
-											Key: "endpoint",
-										},
-									},
-								},
-								corev1.EnvVar{
-									Name: "BOSH_CLIENT",
-									ValueFrom: &corev1.EnvVarSource{
-										SecretKeyRef: &corev1.SecretKeySelector{
-											LocalObjectReference: corev1.LocalObjectReference{
-												Name: secret,
-											},
-											Key: "username",
-										},
-									},
-								},
-								corev1.EnvVar{
-									Name: "BOSH_CLIENT_SECRET",
-									ValueFrom: &corev1.EnvVarSource{
-										SecretKeyRef: &corev1.SecretKeySelector{
-											LocalObjectReference: corev1.LocalObjectReference{
-												Name: secret,
-											},
-											Key: "password",
-										},
-									},
-								},
-								corev1.EnvVar{
-									Name: "BOSH_CA_CERT",
-									ValueFrom: &corev1.EnvVarSource{
-										SecretKeyRef: &corev1.SecretKeySelector{
-											LocalObjectReference: corev1.LocalObjectReference{
-												Name: secret,
-											},
-											Key: "ca",
-										},
-									},
-								},
-							},
+							Env:             append(taskEnv(), directorEnv(secret)...),
 						},
 					},
 				},
@@ -0,0 +1,44 @@
+package v1alpha1_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/starkandwayne/gluon-controller/api/v1alpha1"
+)
+
+var _ = Describe("BOSHStemcell", func() {
+	Describe("Digest", func() {
+		resolved := func(sha1, sha256 string) *v1alpha1.BOSHStemcell {
+			bs := &v1alpha1.BOSHStemcell{}
+			bs.Spec.OS = "ubuntu-jammy"
+			bs.Status.Resolved = &v1alpha1.ResolvedStemcell{SHA1: sha1, SHA256: sha256}
+			return bs
+		}
+
+		DescribeTable("digests of stemcells resolved from the index",
+			func(bs *v1alpha1.BOSHStemcell, expected string) {
+				Expect(bs.Digest()).To(Equal(expected))
+				_, err := v1alpha1.ParseDigests(bs.Digest())
+				Expect(err).ToNot(HaveOccurred())
+			},
+			Entry("with both checksums", resolved(sha1, sha256), "sha256:"+sha256+";sha1:"+sha1),
+			Entry("with only a SHA256", resolved("", sha256), "sha256:"+sha256),
+			Entry("with only a SHA1", resolved(sha1, ""), "sha1:"+sha1),
+		)
+
+		It("has no digest until it has been resolved", func() {
+			bs := &v1alpha1.BOSHStemcell{}
+			bs.Spec.OS = "ubuntu-jammy"
+			Expect(bs.Digest()).To(Equal(""))
+		})
+
+		It("uses the spec's digest for stemcells given by URL", func() {
+			bs := &v1alpha1.BOSHStemcell{}
+			bs.Spec.URL = "https://example.com/stemcell.tgz"
+			bs.Spec.Digest = "sha256:" + sha256
+			Expect(bs.Digest()).To(Equal("sha256:" + sha256))
+		})
+	})
+})
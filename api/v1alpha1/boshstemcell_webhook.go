@@ -0,0 +1,101 @@
+/*
+Gluon - BOSH / CF Orchestration via Kuberenetes API(s)
+
+Copyright (c) 2020 James Hunt
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to
+deal in the Software without restriction, including without limitation the
+rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+sell copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software..
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+IN THE SOFTWARE.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+func (r *BOSHStemcell) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:verbs=create;update;delete,path=/validate-gluon-starkandwayne-com-v1alpha1-boshstemcell,mutating=false,failurePolicy=fail,groups=gluon.starkandwayne.com,resources=boshstemcells,versions=v1alpha1,name=vboshstemcell.gluon.starkandwayne.com
+
+var _ webhook.Validator = &BOSHStemcell{}
+
+// ValidateCreate implements webhook.Validator
+func (r *BOSHStemcell) ValidateCreate() error {
+	if err := r.validateTargets(); err != nil {
+		return err
+	}
+	return r.validateSource()
+}
+
+// ValidateUpdate implements webhook.Validator
+func (r *BOSHStemcell) ValidateUpdate(old runtime.Object) error {
+	if !r.DeletionTimestamp.IsZero() {
+		// let go of it, whatever it looks like
+		return nil
+	}
+	if err := r.validateTargets(); err != nil {
+		return err
+	}
+	return r.validateSource()
+}
+
+// validateTargets makes sure that the stemcell is headed somewhere.
+func (r *BOSHStemcell) validateTargets() error {
+	if r.Spec.Director == "" && r.Spec.ClusterDirector == "" && r.Spec.DirectorTargets.Empty() {
+		return fmt.Errorf("BOSHStemcell %s/%s names no directors to upload to", r.Namespace, r.Name)
+	}
+	return nil
+}
+
+// validateSource makes sure that the stemcell is given either by URL and
+// digest, or by operating system and IaaS, but not both.
+func (r *BOSHStemcell) validateSource() error {
+	if r.Spec.Digest != "" && r.Spec.SHA1 != "" {
+		return fmt.Errorf("BOSHStemcell %s/%s gives both a digest and a (deprecated) sha1; drop the sha1", r.Namespace, r.Name)
+	}
+	digest := specDigest(r.Spec.Digest, r.Spec.SHA1)
+	if r.FromIndex() {
+		if r.Spec.IaaS == "" {
+			return fmt.Errorf("BOSHStemcell %s/%s gives an operating system, but no IaaS", r.Namespace, r.Name)
+		}
+		if r.Spec.URL != "" || digest != "" {
+			return fmt.Errorf("BOSHStemcell %s/%s gives both an operating system and a URL / digest; pick one", r.Namespace, r.Name)
+		}
+		return nil
+	}
+	if r.Spec.URL == "" || digest == "" {
+		return fmt.Errorf("BOSHStemcell %s/%s needs either a URL and digest, or an operating system and IaaS", r.Namespace, r.Name)
+	}
+	if _, err := ParseDigests(digest); err != nil {
+		return fmt.Errorf("BOSHStemcell %s/%s has a bad checksum: %s", r.Namespace, r.Name, err)
+	}
+	return nil
+}
+
+// ValidateDelete implements webhook.Validator
+func (r *BOSHStemcell) ValidateDelete() error {
+	return validateDelete("BOSHStemcell", r)
+}
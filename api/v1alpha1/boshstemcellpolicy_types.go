@@ -0,0 +1,174 @@
+/*
+Gluon - BOSH / CF Orchestration via Kuberenetes API(s)
+
+Copyright (c) 2020 James Hunt
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to
+deal in the Software without restriction, including without limitation the
+rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+sell copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software..
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+IN THE SOFTWARE.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// StemcellPolicyLabel marks the BOSHStemcells that a
+	// BOSHStemcellPolicy created.  BOSHDeployments carrying it are
+	// redeployed whenever that policy rolls out a new stemcell.
+	StemcellPolicyLabel = "gluon.starkandwayne.com/stemcell-policy"
+)
+
+// BOSHStemcellPolicySpec defines the desired state of BOSHStemcellPolicy
+type BOSHStemcellPolicySpec struct {
+	Director        string `json:"director,omitempty"`
+	ClusterDirector string `json:"clusterDirector,omitempty"`
+
+	// DirectorTargets sends the stemcells to several directors.
+	DirectorTargets `json:",inline"`
+
+	// OS, IaaS and Light pick the stemcell, as for a BOSHStemcell
+	// given by operating system.  Version is the range of versions
+	// allowed: an exact version, a version line (i.e. "1.*"), or
+	// "latest" (the default).
+	OS      string `json:"os"`
+	IaaS    string `json:"iaas"`
+	Version string `json:"version,omitempty"`
+	Light   bool   `json:"light,omitempty"`
+
+	// Cache names a BOSHArtifactCache to fetch the stemcells through.
+	Cache string `json:"cache,omitempty"`
+
+	// Interval is how often to check for newer stemcells; every hour,
+	// unless told otherwise.
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// Keep is how many versions to keep on the director (2, unless
+	// told otherwise).  Older versions are deleted, once no deployment
+	// is using them any more.
+	Keep int `json:"keep,omitempty"`
+
+	// Redeploy, if set, redeploys the BOSHDeployments labeled with
+	// gluon.starkandwayne.com/stemcell-policy=<this policy> whenever
+	// a new stemcell has been uploaded (but not for the first one the
+	// policy uploads).  Their manifests have to ask for stemcell
+	// version "latest" for that to change anything.
+	Redeploy bool `json:"redeploy,omitempty"`
+}
+
+// BOSHStemcellPolicyStatus defines the observed state of BOSHStemcellPolicy
+type BOSHStemcellPolicyStatus struct {
+	Ready bool   `json:"ready"`
+	State string `json:"state"`
+
+	// Latest is the newest stemcell version allowed by the policy, as
+	// of the last check; Versions are the versions the policy is
+	// currently keeping on the director, newest first.
+	Latest      string       `json:"latest,omitempty"`
+	LatestSince *metav1.Time `json:"latestSince,omitempty"`
+	Versions    []string     `json:"versions,omitempty"`
+
+	// RolledOut is the stemcell version that the opted-in
+	// BOSHDeployments were last redeployed for.  It starts out as the
+	// first version the policy uploaded, which nothing is redeployed
+	// for.
+	RolledOut string `json:"rolledOut,omitempty"`
+
+	// Error is why the last check failed (if it did).
+	Error     string       `json:"error,omitempty"`
+	CheckedAt *metav1.Time `json:"checkedAt,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// BOSHStemcellPolicy is the Schema for the boshstemcellpolicies API
+// +kubebuilder:resource:path=boshstemcellpolicies,scope=Namespaced,shortName=bsp
+// +kubebuilder:printcolumn:name="Ready",type="boolean",JSONPath=".status.ready"
+// +kubebuilder:printcolumn:name="Latest",type="string",JSONPath=".status.latest"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type BOSHStemcellPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   BOSHStemcellPolicySpec   `json:"spec,omitempty"`
+	Status BOSHStemcellPolicyStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// BOSHStemcellPolicyList contains a list of BOSHStemcellPolicy
+type BOSHStemcellPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BOSHStemcellPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&BOSHStemcellPolicy{}, &BOSHStemcellPolicyList{})
+}
+
+// Interval returns how often to check for newer stemcells.
+func (p *BOSHStemcellPolicy) Interval() time.Duration {
+	if p.Spec.Interval == nil || p.Spec.Interval.Duration <= 0 {
+		return time.Hour
+	}
+	return p.Spec.Interval.Duration
+}
+
+// Keep returns how many stemcell versions to keep on the director.
+func (p *BOSHStemcellPolicy) Keep() int {
+	if p.Spec.Keep <= 0 {
+		return 2
+	}
+	return p.Spec.Keep
+}
+
+// StemcellName returns the name of the BOSHStemcell that uploads the
+// given version of the stemcell.
+func (p *BOSHStemcellPolicy) StemcellName(version string) string {
+	return fmt.Sprintf("%s-%s", p.Name, strings.ReplaceAll(version, ".", "-"))
+}
+
+// Stemcell returns a BOSHStemcell that uploads the given version of the
+// stemcell to the policy's director.
+func (p *BOSHStemcellPolicy) Stemcell(version string) *BOSHStemcell {
+	return &BOSHStemcell{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: p.Namespace,
+			Name:      p.StemcellName(version),
+			Labels: map[string]string{
+				StemcellPolicyLabel: p.Name,
+			},
+		},
+		Spec: BOSHStemcellSpec{
+			Director:        p.Spec.Director,
+			ClusterDirector: p.Spec.ClusterDirector,
+			DirectorTargets: *p.Spec.DirectorTargets.DeepCopy(),
+			OS:              p.Spec.OS,
+			IaaS:            p.Spec.IaaS,
+			Version:         version,
+			Light:           p.Spec.Light,
+			Cache:           p.Spec.Cache,
+		},
+	}
+}
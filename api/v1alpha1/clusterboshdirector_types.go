@@ -0,0 +1,118 @@
+/*
+Gluon - BOSH / CF Orchestration via Kuberenetes API(s)
+
+Copyright (c) 2020 James Hunt
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to
+deal in the Software without restriction, including without limitation the
+rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+sell copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software..
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+IN THE SOFTWARE.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterBOSHDirectorSpec defines the desired state of ClusterBOSHDirector
+type ClusterBOSHDirectorSpec struct {
+	// Secret is the name of the Secret, in the Gluon controller's own
+	// namespace, that holds the `endpoint`, `username`, `password`, and
+	// `ca` keys for talking to the BOSH director.
+	Secret string `json:"secret"`
+
+	// NamespaceSelector picks the namespaces whose BOSHStemcell,
+	// BOSHConfig, and BOSHDeployment objects may target this director.
+	// An empty selector ({}) matches all namespaces; leaving it unset
+	// matches none.
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// Concurrency limits how many operations Gluon will run against
+	// this director at once, across all namespaces.
+	Concurrency *ConcurrencySpec `json:"concurrency,omitempty"`
+
+	// ForwardAlerts, if set, has Gluon generate an ops file (in the
+	// `<name>-cluster-alerts` ConfigMap in the Gluon namespace) that
+	// configures the director's health monitor to forward its alerts
+	// to Gluon.  Applying it to the director is up to you.
+	ForwardAlerts bool `json:"forwardAlerts,omitempty"`
+
+	// WatchEvents, if set, has Gluon mirror the director's events
+	// (à la `bosh events`) as Kubernetes Events, on the resources
+	// they are about.
+	WatchEvents bool `json:"watchEvents,omitempty"`
+}
+
+// ClusterBOSHDirectorStatus defines the observed state of ClusterBOSHDirector
+type ClusterBOSHDirectorStatus struct {
+	Ready bool   `json:"ready"`
+	State string `json:"state"`
+
+	// Inventory summarizes what is on the BOSH director.
+	Inventory *DirectorInventory `json:"inventory,omitempty"`
+
+	// Events tracks the mirroring of director events, if
+	// spec.watchEvents is set.
+	Events *EventsStatus `json:"events,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterBOSHDirector is the Schema for the clusterboshdirectors API
+// +kubebuilder:resource:path=clusterboshdirectors,scope=Cluster,shortName=cbd
+type ClusterBOSHDirector struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterBOSHDirectorSpec   `json:"spec,omitempty"`
+	Status ClusterBOSHDirectorStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterBOSHDirectorList contains a list of ClusterBOSHDirector
+type ClusterBOSHDirectorList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterBOSHDirector `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterBOSHDirector{}, &ClusterBOSHDirectorList{})
+}
+
+// SecretsName returns the name of the copy of the director credentials
+// Secret that gets mirrored into each namespace that uses this director.
+func (cd *ClusterBOSHDirector) SecretsName() string {
+	return ClusterDirectorSecretsName(cd.Name)
+}
+
+func (cd *ClusterBOSHDirector) DirectorLabels() map[string]string {
+	return map[string]string{
+		ClusterDirectorLabel: cd.Name,
+	}
+}
+
+func (cd *ClusterBOSHDirector) MaxConcurrent(operation string) int {
+	return cd.Spec.Concurrency.Limit(operation)
+}
+
+func ClusterDirectorSecretsName(name string) string {
+	return fmt.Sprintf("%s-cluster-secrets", name)
+}
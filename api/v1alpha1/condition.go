@@ -0,0 +1,68 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ConditionDegraded is true while the BOSH health monitor has
+	// recently been complaining about a deployment.
+	ConditionDegraded = "Degraded"
+
+	// ConditionDrifted is true while something that Gluon put on a
+	// director has gone missing from it, behind Gluon's back.
+	ConditionDrifted = "Drifted"
+
+	// ConditionBlocked is true while a create-env director is held
+	// back by something that has to happen first, i.e. a restore of
+	// its state.
+	ConditionBlocked = "Blocked"
+)
+
+// Condition is an observation about some aspect of a resource,
+// à la the conditions on core Kubernetes resources.
+type Condition struct {
+	Type    string                 `json:"type"`
+	Status  corev1.ConditionStatus `json:"status"`
+	Reason  string                 `json:"reason,omitempty"`
+	Message string                 `json:"message,omitempty"`
+
+	LastUpdateTime     metav1.Time `json:"lastUpdateTime,omitempty"`
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+type Conditions []Condition
+
+// Find returns the condition of the given type, or nil if there isn't one.
+func (cs Conditions) Find(t string) *Condition {
+	for i := range cs {
+		if cs[i].Type == t {
+			return &cs[i]
+		}
+	}
+	return nil
+}
+
+// IsTrue returns true if the condition of the given type is there, and true.
+func (cs Conditions) IsTrue(t string) bool {
+	c := cs.Find(t)
+	return c != nil && c.Status == corev1.ConditionTrue
+}
+
+// Set adds (or updates) the condition of the given type.
+func (cs *Conditions) Set(t string, status corev1.ConditionStatus, reason, message string) {
+	now := metav1.Now()
+	c := cs.Find(t)
+	if c == nil {
+		*cs = append(*cs, Condition{Type: t})
+		c = &(*cs)[len(*cs)-1]
+	}
+	if c.Status != status {
+		c.LastTransitionTime = now
+	}
+	c.Status = status
+	c.Reason = reason
+	c.Message = message
+	c.LastUpdateTime = now
+}
@@ -0,0 +1,55 @@
+package v1alpha1
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DeletionPolicy determines what happens on the BOSH side of things
+// when a BOSHDeployment, BOSHStemcell, or BOSHConfig is deleted.
+//
+// Delete (the default) tears down what Gluon put on the director (or
+// IaaS) before letting go of the resource.  Orphan lets go of the
+// resource right away, without touching the director or the IaaS;
+// anything Gluon made in Kubernetes on its behalf is garbage-collected,
+// as usual.  Retain is like Orphan, but also keeps the state volume of
+// a director deployed via `bosh create-env`, so that it can be picked
+// back up by a new BOSHDeployment of the same name.
+//
+// +kubebuilder:validation:Enum=Delete;Orphan;Retain
+type DeletionPolicy string
+
+const (
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+)
+
+const (
+	// ProtectAnnotation, when set to "true", makes Gluon refuse to
+	// delete the resource until it has also been annotated with
+	// ConfirmDeleteAnnotation, set to the resource's UID.
+	ProtectAnnotation       = "gluon.starkandwayne.com/protect"
+	ConfirmDeleteAnnotation = "gluon.starkandwayne.com/confirm-delete"
+)
+
+// Deletes returns true if the BOSH side of things should be torn down.
+func (p DeletionPolicy) Deletes() bool {
+	return p == "" || p == DeletionPolicyDelete
+}
+
+// Protected returns true if the object cannot be deleted (yet).
+func Protected(o metav1.Object) bool {
+	annotations := o.GetAnnotations()
+	return annotations[ProtectAnnotation] == "true" &&
+		annotations[ConfirmDeleteAnnotation] != string(o.GetUID())
+}
+
+func validateDelete(kind string, o metav1.Object) error {
+	if Protected(o) {
+		return fmt.Errorf("%s %s/%s is protected from deletion; annotate it with %s=%s first if you really mean it",
+			kind, o.GetNamespace(), o.GetName(), ConfirmDeleteAnnotation, o.GetUID())
+	}
+	return nil
+}
@@ -13,6 +13,7 @@ import (
 
 type DependencySpec struct {
 	Stemcell   *string `json:"stemcell,omitempty"`
+	Release    *string `json:"release,omitempty"`
 	Deployment *string `json:"deployment,omitempty"`
 	Config     *string `json:"config,omitempty"`
 	Status     string  `json:"status"`
@@ -44,6 +45,20 @@ func (ds DependencySpec) Resolved(c client.Client, ns string) (bool, string, err
 		ready = sc.Status.Ready
 		state = sc.Status.State
 
+	} else if ds.Release != nil {
+		what = fmt.Sprintf("release %s", *ds.Release)
+		rel := &BOSHRelease{}
+		err := c.Get(context.TODO(), types.NamespacedName{Namespace: ns, Name: *ds.Release}, rel)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return false, what, nil
+			}
+			return false, what, err
+		}
+
+		ready = rel.Status.Ready
+		state = rel.Status.State
+
 	} else if ds.Deployment != nil {
 		what = fmt.Sprintf("deployment %s", *ds.Deployment)
 		dep := &BOSHDeployment{}
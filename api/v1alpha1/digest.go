@@ -0,0 +1,90 @@
+package v1alpha1
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const (
+	// DigestAnnotation is set (by the Job) to the digests of the
+	// artifact that a Job actually uploaded to the director.
+	DigestAnnotation = "gluon.starkandwayne.com/digest"
+)
+
+// Digest is a single checksum of an artifact, i.e. sha256:4e9f...
+type Digest struct {
+	Algorithm string
+	Value     string
+}
+
+func (d Digest) String() string {
+	return d.Algorithm + ":" + d.Value
+}
+
+// Digests is one or more checksums of the same artifact, written (as BOSH
+// does) separated by semicolons, i.e. "sha1:a1b2...;sha256:4e9f...".  A
+// bare checksum, without an algorithm, is a SHA1.
+type Digests []Digest
+
+var digestLengths = map[string]int{
+	"sha1":   40,
+	"sha256": 64,
+}
+
+// ParseDigests parses (and validates) a list of digests.
+func ParseDigests(s string) (Digests, error) {
+	var ds Digests
+	for _, part := range strings.Split(s, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		d := Digest{Algorithm: "sha1", Value: part}
+		if i := strings.Index(part, ":"); i >= 0 {
+			d = Digest{Algorithm: strings.ToLower(part[:i]), Value: part[i+1:]}
+		}
+		d.Value = strings.ToLower(d.Value)
+
+		n, ok := digestLengths[d.Algorithm]
+		if !ok {
+			return nil, fmt.Errorf("unsupported digest algorithm '%s' (only sha1 and sha256 are)", d.Algorithm)
+		}
+		if _, err := hex.DecodeString(d.Value); err != nil || len(d.Value) != n {
+			return nil, fmt.Errorf("malformed %s digest '%s'", d.Algorithm, d.Value)
+		}
+		ds = append(ds, d)
+	}
+	if len(ds) == 0 {
+		return nil, fmt.Errorf("no digests given")
+	}
+	return ds, nil
+}
+
+// specDigest returns the checksum(s) given in a spec: digest, or (if
+// that is blank) the deprecated sha1 field, which is read the same way.
+func specDigest(digest, sha1 string) string {
+	if digest != "" {
+		return digest
+	}
+	return sha1
+}
+
+// Strongest returns the strongest of the digests; SHA256 over SHA1.
+func (ds Digests) Strongest() Digest {
+	best := ds[0]
+	for _, d := range ds {
+		if d.Algorithm == "sha256" {
+			best = d
+		}
+	}
+	return best
+}
+
+func (ds Digests) String() string {
+	l := make([]string, len(ds))
+	for i, d := range ds {
+		l[i] = d.String()
+	}
+	return strings.Join(l, ";")
+}
@@ -0,0 +1,83 @@
+package v1alpha1_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/starkandwayne/gluon-controller/api/v1alpha1"
+)
+
+var (
+	sha1   = strings.Repeat("a1", 20)
+	sha256 = strings.Repeat("4e", 32)
+)
+
+var _ = Describe("Digests", func() {
+	DescribeTable("parsing valid digests",
+		func(in string, expected v1alpha1.Digests) {
+			ds, err := v1alpha1.ParseDigests(in)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ds).To(Equal(expected))
+		},
+		Entry("a bare hex value, as a SHA1", sha1,
+			v1alpha1.Digests{{Algorithm: "sha1", Value: sha1}}),
+		Entry("a prefixed SHA1", "sha1:"+sha1,
+			v1alpha1.Digests{{Algorithm: "sha1", Value: sha1}}),
+		Entry("a prefixed SHA256", "sha256:"+sha256,
+			v1alpha1.Digests{{Algorithm: "sha256", Value: sha256}}),
+		Entry("mixed-case prefixes", "SHA1:"+sha1+";Sha256:"+sha256,
+			v1alpha1.Digests{{Algorithm: "sha1", Value: sha1}, {Algorithm: "sha256", Value: sha256}}),
+		Entry("upper-case hex values", "sha256:"+strings.ToUpper(sha256),
+			v1alpha1.Digests{{Algorithm: "sha256", Value: sha256}}),
+		Entry("whitespace around each digest", " sha1:"+sha1+" ; sha256:"+sha256+" ",
+			v1alpha1.Digests{{Algorithm: "sha1", Value: sha1}, {Algorithm: "sha256", Value: sha256}}),
+		Entry("empty segments, which are skipped", ";sha1:"+sha1+";;",
+			v1alpha1.Digests{{Algorithm: "sha1", Value: sha1}}),
+	)
+
+	DescribeTable("rejecting invalid digests",
+		func(in string) {
+			_, err := v1alpha1.ParseDigests(in)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("nothing at all", ""),
+		Entry("only empty segments", " ; ;"),
+		Entry("a SHA1 that is too short", "sha1:"+sha1[2:]),
+		Entry("a SHA1 that is too long", "sha1:"+sha256),
+		Entry("a bare value of SHA256 length", sha256),
+		Entry("a SHA256 that is too short", "sha256:"+sha1),
+		Entry("a SHA256 that is too long", "sha256:"+sha256+"00"),
+		Entry("a value that isn't hex", "sha1:"+strings.Repeat("zz", 20)),
+		Entry("an empty value", "sha256:"),
+		Entry("an unsupported algorithm", "md5:"+strings.Repeat("ab", 16)),
+		Entry("one bad digest among good ones", "sha1:"+sha1+";sha256:"+sha1),
+	)
+
+	It("prefers SHA256 as the strongest digest", func() {
+		ds, err := v1alpha1.ParseDigests("sha1:" + sha1 + ";sha256:" + sha256)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ds.Strongest()).To(Equal(v1alpha1.Digest{Algorithm: "sha256", Value: sha256}))
+
+		ds, err = v1alpha1.ParseDigests(sha1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ds.Strongest().Algorithm).To(Equal("sha1"))
+	})
+
+	It("falls back to the deprecated sha1 field of a spec", func() {
+		bs := &v1alpha1.BOSHStemcell{}
+		bs.Spec.SHA1 = "sha256:" + sha256
+		Expect(bs.Digest()).To(Equal("sha256:" + sha256))
+
+		bs.Spec.Digest = "sha1:" + sha1
+		Expect(bs.Digest()).To(Equal("sha1:" + sha1))
+	})
+
+	It("writes digests back out with their algorithms", func() {
+		ds, err := v1alpha1.ParseDigests(strings.ToUpper(sha1) + "; SHA256:" + sha256)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ds.String()).To(Equal("sha1:" + sha1 + ";sha256:" + sha256))
+	})
+})
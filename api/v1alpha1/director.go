@@ -0,0 +1,120 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	DirectorLabel        = "gluon.starkandwayne.com/director"
+	ClusterDirectorLabel = "gluon.starkandwayne.com/cluster-director"
+	OperationLabel       = "gluon.starkandwayne.com/operation"
+
+	OperationConfig     = "config"
+	OperationStemcell   = "stemcell"
+	OperationRelease    = "release"
+	OperationDeployment = "deployment"
+
+	DefaultConfigConcurrency     = 1
+	DefaultStemcellConcurrency   = 2
+	DefaultReleaseConcurrency    = 2
+	DefaultDeploymentConcurrency = 4
+)
+
+// Director is anything that stemcells, configs, and deployments can be
+// sent to; either a BOSHDeployment that stands up a BOSH director in the
+// same namespace, or a ClusterBOSHDirector shared across namespaces.
+// +kubebuilder:object:generate=false
+type Director interface {
+	GetName() string
+	GetNamespace() string
+	SecretsName() string
+
+	// DirectorLabels returns the labels that identify Jobs which
+	// operate against this director.
+	DirectorLabels() map[string]string
+
+	// MaxConcurrent returns how many operations of the given kind
+	// may run against this director at the same time.
+	MaxConcurrent(operation string) int
+}
+
+// ConcurrencySpec limits how many operations of each kind Gluon will run
+// against a single director at once.  Anything left unset (or zero) gets
+// the default: one config update, two stemcell uploads, two release
+// uploads, and four deploys.
+type ConcurrencySpec struct {
+	Configs     int `json:"configs,omitempty"`
+	Stemcells   int `json:"stemcells,omitempty"`
+	Releases    int `json:"releases,omitempty"`
+	Deployments int `json:"deployments,omitempty"`
+}
+
+func (cs *ConcurrencySpec) Limit(operation string) int {
+	var n, def int
+	switch operation {
+	case OperationConfig:
+		def = DefaultConfigConcurrency
+		if cs != nil {
+			n = cs.Configs
+		}
+	case OperationStemcell:
+		def = DefaultStemcellConcurrency
+		if cs != nil {
+			n = cs.Stemcells
+		}
+	case OperationRelease:
+		def = DefaultReleaseConcurrency
+		if cs != nil {
+			n = cs.Releases
+		}
+	default:
+		def = DefaultDeploymentConcurrency
+		if cs != nil {
+			n = cs.Deployments
+		}
+	}
+
+	if n <= 0 {
+		return def
+	}
+	return n
+}
+
+func directorLabels(name, cluster string, operation string) map[string]string {
+	l := map[string]string{
+		OperationLabel: operation,
+	}
+	if cluster != "" {
+		l[ClusterDirectorLabel] = cluster
+	} else if name != "" {
+		l[DirectorLabel] = name
+	}
+	return l
+}
+
+func jobLabels(director Director, operation string) map[string]string {
+	l := director.DirectorLabels()
+	l[OperationLabel] = operation
+	return l
+}
+
+// directorEnv returns the environment variables that point the bosh CLI
+// at the director whose credentials are in the named Secret.
+func directorEnv(secret string) []corev1.EnvVar {
+	ref := func(key string) *corev1.EnvVarSource {
+		return &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: secret,
+				},
+				Key: key,
+			},
+		}
+	}
+	return []corev1.EnvVar{
+		corev1.EnvVar{Name: "BOSH_ENVIRONMENT", ValueFrom: ref("endpoint")},
+		corev1.EnvVar{Name: "BOSH_CLIENT", ValueFrom: ref("username")},
+		corev1.EnvVar{Name: "BOSH_CLIENT_SECRET", ValueFrom: ref("password")},
+		corev1.EnvVar{Name: "BOSH_CA_CERT", ValueFrom: ref("ca")},
+	}
+}
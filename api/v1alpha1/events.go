@@ -0,0 +1,30 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// TasksAnnotation is set (by the Job itself) to the IDs of all of
+	// the director tasks that a Job has started, comma-separated.
+	TasksAnnotation = "gluon.starkandwayne.com/tasks"
+
+	// EventIDAnnotation and EventTimeAnnotation are set on the
+	// Kubernetes Events that mirror director events, to the ID and
+	// (RFC 3339) timestamp of the director event.
+	EventIDAnnotation   = "gluon.starkandwayne.com/director-event-id"
+	EventTimeAnnotation = "gluon.starkandwayne.com/director-event-time"
+)
+
+// EventsStatus tracks how far Gluon has gotten through a director's
+// events (à la `bosh events`).
+type EventsStatus struct {
+	// Cursor is the ID of the newest director event that Gluon has
+	// mirrored; only events newer than it get mirrored next time.
+	Cursor string `json:"cursor,omitempty"`
+
+	// Error is why the last check failed (if it did).
+	Error string `json:"error,omitempty"`
+
+	CheckedAt *metav1.Time `json:"checkedAt,omitempty"`
+}
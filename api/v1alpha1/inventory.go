@@ -0,0 +1,66 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DirectorInventory summarizes what is actually on a BOSH director, as
+// of the last time Gluon asked it.
+type DirectorInventory struct {
+	// Reachable is false if the director could not be queried;
+	// Error says why.
+	Reachable bool   `json:"reachable"`
+	Error     string `json:"error,omitempty"`
+
+	Name    string `json:"name,omitempty"`
+	UUID    string `json:"uuid,omitempty"`
+	Version string `json:"version,omitempty"`
+	CPI     string `json:"cpi,omitempty"`
+
+	Deployments []InventoryDeployment `json:"deployments,omitempty"`
+	Stemcells   []InventoryStemcell   `json:"stemcells,omitempty"`
+	Releases    []InventoryRelease    `json:"releases,omitempty"`
+	Tasks       []InventoryTask       `json:"tasks,omitempty"`
+	Locks       []InventoryLock       `json:"locks,omitempty"`
+
+	CheckedAt *metav1.Time `json:"checkedAt,omitempty"`
+}
+
+// InventoryDeployment is a deployment on a director, and how its most
+// recent task went.
+type InventoryDeployment struct {
+	Name     string         `json:"name"`
+	LastTask *InventoryTask `json:"lastTask,omitempty"`
+}
+
+// InventoryStemcell is a stemcell uploaded to a director, and the
+// deployments (if any) that are using it.
+type InventoryStemcell struct {
+	Name        string   `json:"name"`
+	Version     string   `json:"version"`
+	OS          string   `json:"os,omitempty"`
+	Deployments []string `json:"deployments,omitempty"`
+}
+
+// InventoryRelease is a release uploaded to a director, and all of its
+// versions that are on the director.
+type InventoryRelease struct {
+	Name     string   `json:"name"`
+	Versions []string `json:"versions,omitempty"`
+}
+
+// InventoryTask is a director task.
+type InventoryTask struct {
+	ID          int    `json:"id"`
+	State       string `json:"state"`
+	Description string `json:"description,omitempty"`
+	User        string `json:"user,omitempty"`
+	Deployment  string `json:"deployment,omitempty"`
+}
+
+// InventoryLock is a lock held by a director task.
+type InventoryLock struct {
+	Type     string   `json:"type"`
+	Resource []string `json:"resource,omitempty"`
+	TaskID   string   `json:"taskID,omitempty"`
+}
@@ -0,0 +1,18 @@
+package v1alpha1
+
+import (
+	"crypto/sha1"
+	"fmt"
+)
+
+// Revision boils any number of strings down into a short, stable hash,
+// suitable for telling one version of a thing apart from another in the
+// names of the Jobs that act on it.
+func Revision(parts ...string) string {
+	h := sha1.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))[0:8]
+}
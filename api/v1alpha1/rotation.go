@@ -0,0 +1,117 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RotationSpec asks for some of the credentials in the vars-store of a
+// BOSH director deployed via `bosh create-env` to be regenerated.
+type RotationSpec struct {
+	// ID identifies this rotation request; setting a new ID starts a
+	// new rotation, even of the same variables.
+	ID string `json:"id"`
+
+	// Variables are the names of the vars-store variables to
+	// regenerate (i.e. admin_password, or director_ssl).  Rotating a
+	// CA (i.e. default_ca) also regenerates every certificate that it
+	// signed.
+	Variables []string `json:"variables"`
+}
+
+const (
+	RotationPhasePending     = "pending"
+	RotationPhaseRedeploying = "redeploying"
+	RotationPhaseDependents  = "dependents"
+	RotationPhaseFinalizing  = "finalizing"
+	RotationPhaseComplete    = "complete"
+	RotationPhaseFailed      = "failed"
+)
+
+// RotationStatus tracks a credential rotation, step by step.
+type RotationStatus struct {
+	ID        string   `json:"id"`
+	Variables []string `json:"variables,omitempty"`
+	Phase     string   `json:"phase"`
+
+	// Dependents are the Jobs that used the director's old
+	// credentials, and have to be re-run with the new ones.
+	Dependents []string `json:"dependents,omitempty"`
+
+	Steps []RotationStep `json:"steps,omitempty"`
+}
+
+// RotationStep is one phase of a rotation, and how it went.
+type RotationStep struct {
+	Phase      string       `json:"phase"`
+	State      string       `json:"state"`
+	Message    string       `json:"message,omitempty"`
+	StartedAt  *metav1.Time `json:"startedAt,omitempty"`
+	FinishedAt *metav1.Time `json:"finishedAt,omitempty"`
+}
+
+// Done returns true if the rotation with the given ID has run its
+// course, successfully or not.
+func (rs *RotationStatus) Done(id string) bool {
+	return rs != nil && rs.ID == id &&
+		(rs.Phase == RotationPhaseComplete || rs.Phase == RotationPhaseFailed)
+}
+
+// Begin moves the rotation into the given phase, recording a new step.
+func (rs *RotationStatus) Begin(phase, message string) {
+	now := metav1.Now()
+	rs.Phase = phase
+	rs.Steps = append(rs.Steps, RotationStep{
+		Phase:     phase,
+		State:     StateResolving,
+		Message:   message,
+		StartedAt: &now,
+	})
+}
+
+// Finish records how the current step went.  A failed step fails the
+// whole rotation.
+func (rs *RotationStatus) Finish(state, message string) {
+	now := metav1.Now()
+	if n := len(rs.Steps); n > 0 && rs.Steps[n-1].FinishedAt == nil {
+		rs.Steps[n-1].State = state
+		rs.Steps[n-1].FinishedAt = &now
+		if message != "" {
+			rs.Steps[n-1].Message = message
+		}
+	}
+	if state == StateFailed {
+		rs.Phase = RotationPhaseFailed
+	}
+}
+
+// RotationJobName returns the name of the Job that regenerates the
+// credentials for the current rotation request, and redeploys the
+// director with them.
+func (bd *BOSHDeployment) RotationJobName() string {
+	id := ""
+	if bd.Spec.Rotate != nil {
+		id = bd.Spec.Rotate.ID
+	}
+	return fmt.Sprintf("%s-%s", bd.JobName("rotate"), Revision(id))
+}
+
+func (bd *BOSHDeployment) RotationJob() *batchv1.Job {
+	job := bd.job("rotate")
+	job.Name = bd.RotationJobName()
+
+	vars := []string{}
+	if bd.Spec.Rotate != nil {
+		vars = bd.Spec.Rotate.Variables
+	}
+	job.Spec.Template.Spec.Containers[0].Env = append(job.Spec.Template.Spec.Containers[0].Env,
+		corev1.EnvVar{
+			Name:  "ROTATE_VARIABLES",
+			Value: strings.Join(vars, " "),
+		})
+	return job
+}
@@ -6,9 +6,13 @@ import (
 
 const (
 	StatePending   = "pending"
+	StateQueued    = "queued"
 	StateResolving = "resolving"
 	StateResolved  = "resolved"
 	StateFailed    = "failed"
+
+	StateCancelling = "cancelling"
+	StateCancelled  = "cancelled"
 )
 
 func DetermineReadiness(job *batchv1.Job) (bool, string) {
@@ -0,0 +1,97 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DirectorTargets picks out a set of BOSH directors, by name and / or by
+// label.  Directors are BOSHDeployments (deployed via `bosh create-env`)
+// in the same namespace; cluster directors are ClusterBOSHDirectors
+// that the namespace is allowed to use.
+type DirectorTargets struct {
+	Directors        []string `json:"directors,omitempty"`
+	ClusterDirectors []string `json:"clusterDirectors,omitempty"`
+
+	DirectorSelector        *metav1.LabelSelector `json:"directorSelector,omitempty"`
+	ClusterDirectorSelector *metav1.LabelSelector `json:"clusterDirectorSelector,omitempty"`
+}
+
+// Empty returns true if no directors are picked out at all.
+func (t DirectorTargets) Empty() bool {
+	return len(t.Directors) == 0 && len(t.ClusterDirectors) == 0 &&
+		t.DirectorSelector == nil && t.ClusterDirectorSelector == nil
+}
+
+// DirectorStatus tracks how things went on one of several directors.
+type DirectorStatus struct {
+	// Director or ClusterDirector names the director.
+	Director        string `json:"director,omitempty"`
+	ClusterDirector string `json:"clusterDirector,omitempty"`
+
+	Ready bool   `json:"ready"`
+	State string `json:"state"`
+
+	// CurrentTask follows the director task started by the most
+	// recent Job against this director, as it runs.
+	CurrentTask *TaskStatus `json:"currentTask,omitempty"`
+
+	// Retries counts how many times a failed Job against this
+	// director has been run again (see RetryFailed).
+	Retries int `json:"retries,omitempty"`
+}
+
+// Is returns true if this is the status for the given director.
+func (s *DirectorStatus) Is(director Director) bool {
+	if _, ok := director.(*ClusterBOSHDirector); ok {
+		return s.ClusterDirector == director.GetName()
+	}
+	return s.ClusterDirector == "" && s.Director == director.GetName()
+}
+
+// Name returns the name of the director, or cluster director.
+func (s *DirectorStatus) Name() string {
+	if s.ClusterDirector != "" {
+		return s.ClusterDirector
+	}
+	return s.Director
+}
+
+// jobTarget names director in the names of the Jobs that act on it.
+// Cluster directors get a "cluster-" prefix, so that they don't collide
+// with namespaced directors of the same name.
+func jobTarget(director Director) string {
+	if _, ok := director.(*ClusterBOSHDirector); ok {
+		return "cluster-" + director.GetName()
+	}
+	return director.GetName()
+}
+
+// newDirectorStatus starts a status for the given director.
+func newDirectorStatus(director Director) DirectorStatus {
+	if _, ok := director.(*ClusterBOSHDirector); ok {
+		return DirectorStatus{ClusterDirector: director.GetName(), State: StatePending}
+	}
+	return DirectorStatus{Director: director.GetName(), State: StatePending}
+}
+
+// Aggregate sums up the state of things across several directors: ready
+// only if ready everywhere, and otherwise in the least-far-along state.
+func Aggregate(statuses []DirectorStatus) (bool, string) {
+	if len(statuses) == 0 {
+		return false, StatePending
+	}
+
+	// from worst to best
+	order := []string{StateFailed, StateCancelling, StateCancelled, StatePending, StateQueued, StateResolving, StateResolved}
+	worst := len(order) - 1
+	ready := true
+	for _, s := range statuses {
+		ready = ready && s.Ready
+		for i, state := range order {
+			if s.State == state && i < worst {
+				worst = i
+			}
+		}
+	}
+	return ready, order[worst]
+}
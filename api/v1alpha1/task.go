@@ -0,0 +1,61 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// TaskAnnotation is set (by the Job itself) to the ID of the
+	// director task that a Job has most recently started.
+	TaskAnnotation = "gluon.starkandwayne.com/task"
+)
+
+// TaskStatus follows a director task, as it runs.
+type TaskStatus struct {
+	ID          int    `json:"id"`
+	State       string `json:"state"`
+	Description string `json:"description,omitempty"`
+
+	// Stage is what the task is doing right now (i.e. "Updating
+	// instance"), and Progress how far along it is in that stage
+	// (i.e. "3/20").
+	Stage         string `json:"stage,omitempty"`
+	InstanceGroup string `json:"instanceGroup,omitempty"`
+	Progress      string `json:"progress,omitempty"`
+
+	// Summary sums all that up, for `kubectl get`, as in
+	// "updating instance diego-cell (3/20)"
+	Summary string `json:"summary,omitempty"`
+
+	// Error is why the task failed (if it did).
+	Error string `json:"error,omitempty"`
+}
+
+// taskEnv tells Jobs who they are, so that they can report the director
+// tasks they start (see TaskAnnotation).
+func taskEnv() []corev1.EnvVar {
+	return []corev1.EnvVar{
+		corev1.EnvVar{
+			Name: "POD_NAMESPACE",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{
+					APIVersion: "v1",
+					FieldPath:  "metadata.namespace",
+				},
+			},
+		},
+		jobNameEnv(),
+	}
+}
+
+func jobNameEnv() corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: "JOB_NAME",
+		ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{
+				APIVersion: "v1",
+				FieldPath:  "metadata.labels['job-name']",
+			},
+		},
+	}
+}
@@ -10,7 +10,9 @@ import (
 var (
 	GluonVersion    string
 	GluonImage      string
+	CacheImage      string
 	GluonPullPolicy corev1.PullPolicy
+	GluonNamespace  string
 )
 
 func init() {
@@ -30,9 +32,27 @@ func init() {
 		GluonImage = "starkandwayne/gluon-apparatus:" + GluonVersion
 	}
 
+	// artifact caches run the artifact-cache binary that ships in
+	// the controller image, rather than the apparatus
+	if v := os.Getenv("GLUON_CACHE_IMAGE"); v != "" {
+		if strings.Contains(v, ":") {
+			CacheImage = v
+		} else {
+			CacheImage = v + ":" + GluonVersion
+		}
+	} else {
+		CacheImage = "starkandwayne/gluon-controller:" + GluonVersion
+	}
+
 	if v := os.Getenv("GLUON_PULL_POLICY"); v != "" {
 		GluonPullPolicy = corev1.PullPolicy(v)
 	} else {
 		GluonPullPolicy = corev1.PullIfNotPresent
 	}
+
+	if v := os.Getenv("GLUON_NAMESPACE"); v != "" {
+		GluonNamespace = v
+	} else {
+		GluonNamespace = "gluon-controller-system"
+	}
 }
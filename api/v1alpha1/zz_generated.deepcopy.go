@@ -29,9 +29,221 @@ IN THE SOFTWARE.
 package v1alpha1
 
 import (
-	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BOSHArtifactCache) DeepCopyInto(out *BOSHArtifactCache) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHArtifactCache.
+func (in *BOSHArtifactCache) DeepCopy() *BOSHArtifactCache {
+	if in == nil {
+		return nil
+	}
+	out := new(BOSHArtifactCache)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BOSHArtifactCache) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BOSHArtifactCacheList) DeepCopyInto(out *BOSHArtifactCacheList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]BOSHArtifactCache, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHArtifactCacheList.
+func (in *BOSHArtifactCacheList) DeepCopy() *BOSHArtifactCacheList {
+	if in == nil {
+		return nil
+	}
+	out := new(BOSHArtifactCacheList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BOSHArtifactCacheList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BOSHArtifactCacheSpec) DeepCopyInto(out *BOSHArtifactCacheSpec) {
+	*out = *in
+	out.Size = in.Size.DeepCopy()
+	if in.StorageClassName != nil {
+		in, out := &in.StorageClassName, &out.StorageClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.MaxSize != nil {
+		in, out := &in.MaxSize, &out.MaxSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHArtifactCacheSpec.
+func (in *BOSHArtifactCacheSpec) DeepCopy() *BOSHArtifactCacheSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BOSHArtifactCacheSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BOSHArtifactCacheStatus) DeepCopyInto(out *BOSHArtifactCacheStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHArtifactCacheStatus.
+func (in *BOSHArtifactCacheStatus) DeepCopy() *BOSHArtifactCacheStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BOSHArtifactCacheStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BOSHCompiledRelease) DeepCopyInto(out *BOSHCompiledRelease) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Dependencies.DeepCopyInto(&out.Dependencies)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHCompiledRelease.
+func (in *BOSHCompiledRelease) DeepCopy() *BOSHCompiledRelease {
+	if in == nil {
+		return nil
+	}
+	out := new(BOSHCompiledRelease)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BOSHCompiledRelease) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BOSHCompiledReleaseList) DeepCopyInto(out *BOSHCompiledReleaseList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]BOSHCompiledRelease, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHCompiledReleaseList.
+func (in *BOSHCompiledReleaseList) DeepCopy() *BOSHCompiledReleaseList {
+	if in == nil {
+		return nil
+	}
+	out := new(BOSHCompiledReleaseList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BOSHCompiledReleaseList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BOSHCompiledReleaseSpec) DeepCopyInto(out *BOSHCompiledReleaseSpec) {
+	*out = *in
+	out.Release = in.Release
+	out.Stemcell = in.Stemcell
+	in.UploadTo.DeepCopyInto(&out.UploadTo)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHCompiledReleaseSpec.
+func (in *BOSHCompiledReleaseSpec) DeepCopy() *BOSHCompiledReleaseSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BOSHCompiledReleaseSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BOSHCompiledReleaseStatus) DeepCopyInto(out *BOSHCompiledReleaseStatus) {
+	*out = *in
+	if in.CurrentTask != nil {
+		in, out := &in.CurrentTask, &out.CurrentTask
+		*out = new(TaskStatus)
+		**out = **in
+	}
+	in.Export.DeepCopyInto(&out.Export)
+	if in.Exported != nil {
+		in, out := &in.Exported, &out.Exported
+		*out = new(ExportedRelease)
+		**out = **in
+	}
+	if in.Directors != nil {
+		in, out := &in.Directors, &out.Directors
+		*out = make([]ReleaseDirectorStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHCompiledReleaseStatus.
+func (in *BOSHCompiledReleaseStatus) DeepCopy() *BOSHCompiledReleaseStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BOSHCompiledReleaseStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BOSHConfig) DeepCopyInto(out *BOSHConfig) {
 	*out = *in
@@ -39,7 +251,7 @@ func (in *BOSHConfig) DeepCopyInto(out *BOSHConfig) {
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	out.Spec = in.Spec
 	in.Dependencies.DeepCopyInto(&out.Dependencies)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHConfig.
@@ -110,6 +322,15 @@ func (in *BOSHConfigSpec) DeepCopy() *BOSHConfigSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BOSHConfigStatus) DeepCopyInto(out *BOSHConfigStatus) {
 	*out = *in
+	if in.CurrentTask != nil {
+		in, out := &in.CurrentTask, &out.CurrentTask
+		*out = new(TaskStatus)
+		**out = **in
+	}
+	if in.UpdatedAt != nil {
+		in, out := &in.UpdatedAt, &out.UpdatedAt
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHConfigStatus.
@@ -129,7 +350,7 @@ func (in *BOSHDeployment) DeepCopyInto(out *BOSHDeployment) {
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
 	in.Dependencies.DeepCopyInto(&out.Dependencies)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHDeployment.
@@ -197,6 +418,21 @@ func (in *BOSHDeploymentSpec) DeepCopyInto(out *BOSHDeploymentSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Concurrency != nil {
+		in, out := &in.Concurrency, &out.Concurrency
+		*out = new(ConcurrencySpec)
+		**out = **in
+	}
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(UpgradeSpec)
+		**out = **in
+	}
+	if in.Rotate != nil {
+		in, out := &in.Rotate, &out.Rotate
+		*out = new(RotationSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHDeploymentSpec.
@@ -212,6 +448,43 @@ func (in *BOSHDeploymentSpec) DeepCopy() *BOSHDeploymentSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BOSHDeploymentStatus) DeepCopyInto(out *BOSHDeploymentStatus) {
 	*out = *in
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(UpgradeStatus)
+		**out = **in
+	}
+	if in.Rotation != nil {
+		in, out := &in.Rotation, &out.Rotation
+		*out = new(RotationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Inventory != nil {
+		in, out := &in.Inventory, &out.Inventory
+		*out = new(DirectorInventory)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Events != nil {
+		in, out := &in.Events, &out.Events
+		*out = new(EventsStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LastTask != nil {
+		in, out := &in.LastTask, &out.LastTask
+		*out = new(InventoryTask)
+		**out = **in
+	}
+	if in.CurrentTask != nil {
+		in, out := &in.CurrentTask, &out.CurrentTask
+		*out = new(TaskStatus)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHDeploymentStatus.
@@ -225,27 +498,26 @@ func (in *BOSHDeploymentStatus) DeepCopy() *BOSHDeploymentStatus {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *BOSHStemcell) DeepCopyInto(out *BOSHStemcell) {
+func (in *BOSHDirectorBackup) DeepCopyInto(out *BOSHDirectorBackup) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	out.Spec = in.Spec
-	in.Dependencies.DeepCopyInto(&out.Dependencies)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHStemcell.
-func (in *BOSHStemcell) DeepCopy() *BOSHStemcell {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHDirectorBackup.
+func (in *BOSHDirectorBackup) DeepCopy() *BOSHDirectorBackup {
 	if in == nil {
 		return nil
 	}
-	out := new(BOSHStemcell)
+	out := new(BOSHDirectorBackup)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *BOSHStemcell) DeepCopyObject() runtime.Object {
+func (in *BOSHDirectorBackup) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -253,31 +525,31 @@ func (in *BOSHStemcell) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *BOSHStemcellList) DeepCopyInto(out *BOSHStemcellList) {
+func (in *BOSHDirectorBackupList) DeepCopyInto(out *BOSHDirectorBackupList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]BOSHStemcell, len(*in))
+		*out = make([]BOSHDirectorBackup, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHStemcellList.
-func (in *BOSHStemcellList) DeepCopy() *BOSHStemcellList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHDirectorBackupList.
+func (in *BOSHDirectorBackupList) DeepCopy() *BOSHDirectorBackupList {
 	if in == nil {
 		return nil
 	}
-	out := new(BOSHStemcellList)
+	out := new(BOSHDirectorBackupList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *BOSHStemcellList) DeepCopyObject() runtime.Object {
+func (in *BOSHDirectorBackupList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -285,89 +557,682 @@ func (in *BOSHStemcellList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *BOSHStemcellSpec) DeepCopyInto(out *BOSHStemcellSpec) {
+func (in *BOSHDirectorBackupSpec) DeepCopyInto(out *BOSHDirectorBackupSpec) {
 	*out = *in
+	out.Storage = in.Storage
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHStemcellSpec.
-func (in *BOSHStemcellSpec) DeepCopy() *BOSHStemcellSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHDirectorBackupSpec.
+func (in *BOSHDirectorBackupSpec) DeepCopy() *BOSHDirectorBackupSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(BOSHStemcellSpec)
+	out := new(BOSHDirectorBackupSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *BOSHStemcellStatus) DeepCopyInto(out *BOSHStemcellStatus) {
+func (in *BOSHDirectorBackupStatus) DeepCopyInto(out *BOSHDirectorBackupStatus) {
 	*out = *in
+	if in.LastBackupTime != nil {
+		in, out := &in.LastBackupTime, &out.LastBackupTime
+		*out = (*in).DeepCopy()
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHStemcellStatus.
-func (in *BOSHStemcellStatus) DeepCopy() *BOSHStemcellStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHDirectorBackupStatus.
+func (in *BOSHDirectorBackupStatus) DeepCopy() *BOSHDirectorBackupStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(BOSHStemcellStatus)
+	out := new(BOSHDirectorBackupStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ConfigMapVariableSource) DeepCopyInto(out *ConfigMapVariableSource) {
+func (in *BOSHDirectorRestore) DeepCopyInto(out *BOSHDirectorRestore) {
 	*out = *in
-	if in.MapKeys != nil {
-		in, out := &in.MapKeys, &out.MapKeys
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	out.Status = in.Status
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigMapVariableSource.
-func (in *ConfigMapVariableSource) DeepCopy() *ConfigMapVariableSource {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHDirectorRestore.
+func (in *BOSHDirectorRestore) DeepCopy() *BOSHDirectorRestore {
 	if in == nil {
 		return nil
 	}
-	out := new(ConfigMapVariableSource)
+	out := new(BOSHDirectorRestore)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BOSHDirectorRestore) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DependencySpec) DeepCopyInto(out *DependencySpec) {
+func (in *BOSHDirectorRestoreList) DeepCopyInto(out *BOSHDirectorRestoreList) {
 	*out = *in
-	if in.Stemcell != nil {
-		in, out := &in.Stemcell, &out.Stemcell
-		*out = new(string)
-		**out = **in
-	}
-	if in.Deployment != nil {
-		in, out := &in.Deployment, &out.Deployment
-		*out = new(string)
-		**out = **in
-	}
-	if in.Config != nil {
-		in, out := &in.Config, &out.Config
-		*out = new(string)
-		**out = **in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]BOSHDirectorRestore, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DependencySpec.
-func (in *DependencySpec) DeepCopy() *DependencySpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHDirectorRestoreList.
+func (in *BOSHDirectorRestoreList) DeepCopy() *BOSHDirectorRestoreList {
 	if in == nil {
 		return nil
 	}
-	out := new(DependencySpec)
+	out := new(BOSHDirectorRestoreList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BOSHDirectorRestoreList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DependencySpecs) DeepCopyInto(out *DependencySpecs) {
+func (in *BOSHDirectorRestoreSpec) DeepCopyInto(out *BOSHDirectorRestoreSpec) {
+	*out = *in
+	out.Storage = in.Storage
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHDirectorRestoreSpec.
+func (in *BOSHDirectorRestoreSpec) DeepCopy() *BOSHDirectorRestoreSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BOSHDirectorRestoreSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BOSHDirectorRestoreStatus) DeepCopyInto(out *BOSHDirectorRestoreStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHDirectorRestoreStatus.
+func (in *BOSHDirectorRestoreStatus) DeepCopy() *BOSHDirectorRestoreStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BOSHDirectorRestoreStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BOSHRelease) DeepCopyInto(out *BOSHRelease) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Dependencies.DeepCopyInto(&out.Dependencies)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHRelease.
+func (in *BOSHRelease) DeepCopy() *BOSHRelease {
+	if in == nil {
+		return nil
+	}
+	out := new(BOSHRelease)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BOSHRelease) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BOSHReleaseList) DeepCopyInto(out *BOSHReleaseList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]BOSHRelease, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHReleaseList.
+func (in *BOSHReleaseList) DeepCopy() *BOSHReleaseList {
+	if in == nil {
+		return nil
+	}
+	out := new(BOSHReleaseList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BOSHReleaseList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BOSHReleaseSpec) DeepCopyInto(out *BOSHReleaseSpec) {
+	*out = *in
+	in.DirectorTargets.DeepCopyInto(&out.DirectorTargets)
+	if in.Git != nil {
+		in, out := &in.Git, &out.Git
+		*out = new(ReleaseGitSpec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHReleaseSpec.
+func (in *BOSHReleaseSpec) DeepCopy() *BOSHReleaseSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BOSHReleaseSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BOSHReleaseStatus) DeepCopyInto(out *BOSHReleaseStatus) {
+	*out = *in
+	if in.CurrentTask != nil {
+		in, out := &in.CurrentTask, &out.CurrentTask
+		*out = new(TaskStatus)
+		**out = **in
+	}
+	if in.Directors != nil {
+		in, out := &in.Directors, &out.Directors
+		*out = make([]ReleaseDirectorStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHReleaseStatus.
+func (in *BOSHReleaseStatus) DeepCopy() *BOSHReleaseStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BOSHReleaseStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BOSHStemcell) DeepCopyInto(out *BOSHStemcell) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Dependencies.DeepCopyInto(&out.Dependencies)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHStemcell.
+func (in *BOSHStemcell) DeepCopy() *BOSHStemcell {
+	if in == nil {
+		return nil
+	}
+	out := new(BOSHStemcell)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BOSHStemcell) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BOSHStemcellList) DeepCopyInto(out *BOSHStemcellList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]BOSHStemcell, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHStemcellList.
+func (in *BOSHStemcellList) DeepCopy() *BOSHStemcellList {
+	if in == nil {
+		return nil
+	}
+	out := new(BOSHStemcellList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BOSHStemcellList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BOSHStemcellPolicy) DeepCopyInto(out *BOSHStemcellPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHStemcellPolicy.
+func (in *BOSHStemcellPolicy) DeepCopy() *BOSHStemcellPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(BOSHStemcellPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BOSHStemcellPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BOSHStemcellPolicyList) DeepCopyInto(out *BOSHStemcellPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]BOSHStemcellPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHStemcellPolicyList.
+func (in *BOSHStemcellPolicyList) DeepCopy() *BOSHStemcellPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(BOSHStemcellPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BOSHStemcellPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BOSHStemcellPolicySpec) DeepCopyInto(out *BOSHStemcellPolicySpec) {
+	*out = *in
+	in.DirectorTargets.DeepCopyInto(&out.DirectorTargets)
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHStemcellPolicySpec.
+func (in *BOSHStemcellPolicySpec) DeepCopy() *BOSHStemcellPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BOSHStemcellPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BOSHStemcellPolicyStatus) DeepCopyInto(out *BOSHStemcellPolicyStatus) {
+	*out = *in
+	if in.LatestSince != nil {
+		in, out := &in.LatestSince, &out.LatestSince
+		*out = (*in).DeepCopy()
+	}
+	if in.Versions != nil {
+		in, out := &in.Versions, &out.Versions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CheckedAt != nil {
+		in, out := &in.CheckedAt, &out.CheckedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHStemcellPolicyStatus.
+func (in *BOSHStemcellPolicyStatus) DeepCopy() *BOSHStemcellPolicyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BOSHStemcellPolicyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BOSHStemcellSpec) DeepCopyInto(out *BOSHStemcellSpec) {
+	*out = *in
+	in.DirectorTargets.DeepCopyInto(&out.DirectorTargets)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHStemcellSpec.
+func (in *BOSHStemcellSpec) DeepCopy() *BOSHStemcellSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BOSHStemcellSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BOSHStemcellStatus) DeepCopyInto(out *BOSHStemcellStatus) {
+	*out = *in
+	if in.CurrentTask != nil {
+		in, out := &in.CurrentTask, &out.CurrentTask
+		*out = new(TaskStatus)
+		**out = **in
+	}
+	if in.Directors != nil {
+		in, out := &in.Directors, &out.Directors
+		*out = make([]StemcellDirectorStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Resolved != nil {
+		in, out := &in.Resolved, &out.Resolved
+		*out = new(ResolvedStemcell)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BOSHStemcellStatus.
+func (in *BOSHStemcellStatus) DeepCopy() *BOSHStemcellStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BOSHStemcellStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterBOSHDirector) DeepCopyInto(out *ClusterBOSHDirector) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterBOSHDirector.
+func (in *ClusterBOSHDirector) DeepCopy() *ClusterBOSHDirector {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterBOSHDirector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterBOSHDirector) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterBOSHDirectorList) DeepCopyInto(out *ClusterBOSHDirectorList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterBOSHDirector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterBOSHDirectorList.
+func (in *ClusterBOSHDirectorList) DeepCopy() *ClusterBOSHDirectorList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterBOSHDirectorList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterBOSHDirectorList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterBOSHDirectorSpec) DeepCopyInto(out *ClusterBOSHDirectorSpec) {
+	*out = *in
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Concurrency != nil {
+		in, out := &in.Concurrency, &out.Concurrency
+		*out = new(ConcurrencySpec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterBOSHDirectorSpec.
+func (in *ClusterBOSHDirectorSpec) DeepCopy() *ClusterBOSHDirectorSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterBOSHDirectorSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterBOSHDirectorStatus) DeepCopyInto(out *ClusterBOSHDirectorStatus) {
+	*out = *in
+	if in.Inventory != nil {
+		in, out := &in.Inventory, &out.Inventory
+		*out = new(DirectorInventory)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Events != nil {
+		in, out := &in.Events, &out.Events
+		*out = new(EventsStatus)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterBOSHDirectorStatus.
+func (in *ClusterBOSHDirectorStatus) DeepCopy() *ClusterBOSHDirectorStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterBOSHDirectorStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConcurrencySpec) DeepCopyInto(out *ConcurrencySpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConcurrencySpec.
+func (in *ConcurrencySpec) DeepCopy() *ConcurrencySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ConcurrencySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Condition) DeepCopyInto(out *Condition) {
+	*out = *in
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Condition.
+func (in *Condition) DeepCopy() *Condition {
+	if in == nil {
+		return nil
+	}
+	out := new(Condition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in Conditions) DeepCopyInto(out *Conditions) {
+	{
+		in := &in
+		*out = make(Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Conditions.
+func (in Conditions) DeepCopy() Conditions {
+	if in == nil {
+		return nil
+	}
+	out := new(Conditions)
+	in.DeepCopyInto(out)
+	return *out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigMapVariableSource) DeepCopyInto(out *ConfigMapVariableSource) {
+	*out = *in
+	if in.MapKeys != nil {
+		in, out := &in.MapKeys, &out.MapKeys
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigMapVariableSource.
+func (in *ConfigMapVariableSource) DeepCopy() *ConfigMapVariableSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigMapVariableSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DependencySpec) DeepCopyInto(out *DependencySpec) {
+	*out = *in
+	if in.Stemcell != nil {
+		in, out := &in.Stemcell, &out.Stemcell
+		*out = new(string)
+		**out = **in
+	}
+	if in.Release != nil {
+		in, out := &in.Release, &out.Release
+		*out = new(string)
+		**out = **in
+	}
+	if in.Deployment != nil {
+		in, out := &in.Deployment, &out.Deployment
+		*out = new(string)
+		**out = **in
+	}
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DependencySpec.
+func (in *DependencySpec) DeepCopy() *DependencySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DependencySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DependencySpecs) DeepCopyInto(out *DependencySpecs) {
 	*out = *in
 	if in.Dependencies != nil {
 		in, out := &in.Dependencies, &out.Dependencies
@@ -378,12 +1243,440 @@ func (in *DependencySpecs) DeepCopyInto(out *DependencySpecs) {
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DependencySpecs.
-func (in *DependencySpecs) DeepCopy() *DependencySpecs {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DependencySpecs.
+func (in *DependencySpecs) DeepCopy() *DependencySpecs {
+	if in == nil {
+		return nil
+	}
+	out := new(DependencySpecs)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Digest) DeepCopyInto(out *Digest) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Digest.
+func (in *Digest) DeepCopy() *Digest {
+	if in == nil {
+		return nil
+	}
+	out := new(Digest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in Digests) DeepCopyInto(out *Digests) {
+	{
+		in := &in
+		*out = make(Digests, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Digests.
+func (in Digests) DeepCopy() Digests {
+	if in == nil {
+		return nil
+	}
+	out := new(Digests)
+	in.DeepCopyInto(out)
+	return *out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DirectorInventory) DeepCopyInto(out *DirectorInventory) {
+	*out = *in
+	if in.Deployments != nil {
+		in, out := &in.Deployments, &out.Deployments
+		*out = make([]InventoryDeployment, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Stemcells != nil {
+		in, out := &in.Stemcells, &out.Stemcells
+		*out = make([]InventoryStemcell, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Releases != nil {
+		in, out := &in.Releases, &out.Releases
+		*out = make([]InventoryRelease, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Tasks != nil {
+		in, out := &in.Tasks, &out.Tasks
+		*out = make([]InventoryTask, len(*in))
+		copy(*out, *in)
+	}
+	if in.Locks != nil {
+		in, out := &in.Locks, &out.Locks
+		*out = make([]InventoryLock, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CheckedAt != nil {
+		in, out := &in.CheckedAt, &out.CheckedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DirectorInventory.
+func (in *DirectorInventory) DeepCopy() *DirectorInventory {
+	if in == nil {
+		return nil
+	}
+	out := new(DirectorInventory)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DirectorStatus) DeepCopyInto(out *DirectorStatus) {
+	*out = *in
+	if in.CurrentTask != nil {
+		in, out := &in.CurrentTask, &out.CurrentTask
+		*out = new(TaskStatus)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DirectorStatus.
+func (in *DirectorStatus) DeepCopy() *DirectorStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DirectorStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DirectorTargets) DeepCopyInto(out *DirectorTargets) {
+	*out = *in
+	if in.Directors != nil {
+		in, out := &in.Directors, &out.Directors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ClusterDirectors != nil {
+		in, out := &in.ClusterDirectors, &out.ClusterDirectors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DirectorSelector != nil {
+		in, out := &in.DirectorSelector, &out.DirectorSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClusterDirectorSelector != nil {
+		in, out := &in.ClusterDirectorSelector, &out.ClusterDirectorSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DirectorTargets.
+func (in *DirectorTargets) DeepCopy() *DirectorTargets {
+	if in == nil {
+		return nil
+	}
+	out := new(DirectorTargets)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EventsStatus) DeepCopyInto(out *EventsStatus) {
+	*out = *in
+	if in.CheckedAt != nil {
+		in, out := &in.CheckedAt, &out.CheckedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EventsStatus.
+func (in *EventsStatus) DeepCopy() *EventsStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EventsStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExportedRelease) DeepCopyInto(out *ExportedRelease) {
+	*out = *in
+	out.Release = in.Release
+	out.Stemcell = in.Stemcell
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExportedRelease.
+func (in *ExportedRelease) DeepCopy() *ExportedRelease {
+	if in == nil {
+		return nil
+	}
+	out := new(ExportedRelease)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InventoryDeployment) DeepCopyInto(out *InventoryDeployment) {
+	*out = *in
+	if in.LastTask != nil {
+		in, out := &in.LastTask, &out.LastTask
+		*out = new(InventoryTask)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InventoryDeployment.
+func (in *InventoryDeployment) DeepCopy() *InventoryDeployment {
+	if in == nil {
+		return nil
+	}
+	out := new(InventoryDeployment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InventoryLock) DeepCopyInto(out *InventoryLock) {
+	*out = *in
+	if in.Resource != nil {
+		in, out := &in.Resource, &out.Resource
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InventoryLock.
+func (in *InventoryLock) DeepCopy() *InventoryLock {
+	if in == nil {
+		return nil
+	}
+	out := new(InventoryLock)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InventoryRelease) DeepCopyInto(out *InventoryRelease) {
+	*out = *in
+	if in.Versions != nil {
+		in, out := &in.Versions, &out.Versions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InventoryRelease.
+func (in *InventoryRelease) DeepCopy() *InventoryRelease {
+	if in == nil {
+		return nil
+	}
+	out := new(InventoryRelease)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InventoryStemcell) DeepCopyInto(out *InventoryStemcell) {
+	*out = *in
+	if in.Deployments != nil {
+		in, out := &in.Deployments, &out.Deployments
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InventoryStemcell.
+func (in *InventoryStemcell) DeepCopy() *InventoryStemcell {
+	if in == nil {
+		return nil
+	}
+	out := new(InventoryStemcell)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InventoryTask) DeepCopyInto(out *InventoryTask) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InventoryTask.
+func (in *InventoryTask) DeepCopy() *InventoryTask {
+	if in == nil {
+		return nil
+	}
+	out := new(InventoryTask)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectStorageSpec) DeepCopyInto(out *ObjectStorageSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectStorageSpec.
+func (in *ObjectStorageSpec) DeepCopy() *ObjectStorageSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectStorageSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseDirectorStatus) DeepCopyInto(out *ReleaseDirectorStatus) {
+	*out = *in
+	in.DirectorStatus.DeepCopyInto(&out.DirectorStatus)
+	if in.Uploaded != nil {
+		in, out := &in.Uploaded, &out.Uploaded
+		*out = new(UploadedRelease)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseDirectorStatus.
+func (in *ReleaseDirectorStatus) DeepCopy() *ReleaseDirectorStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseDirectorStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseGitSpec) DeepCopyInto(out *ReleaseGitSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseGitSpec.
+func (in *ReleaseGitSpec) DeepCopy() *ReleaseGitSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseGitSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseRef) DeepCopyInto(out *ReleaseRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseRef.
+func (in *ReleaseRef) DeepCopy() *ReleaseRef {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResolvedStemcell) DeepCopyInto(out *ResolvedStemcell) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResolvedStemcell.
+func (in *ResolvedStemcell) DeepCopy() *ResolvedStemcell {
+	if in == nil {
+		return nil
+	}
+	out := new(ResolvedStemcell)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RotationSpec) DeepCopyInto(out *RotationSpec) {
+	*out = *in
+	if in.Variables != nil {
+		in, out := &in.Variables, &out.Variables
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RotationSpec.
+func (in *RotationSpec) DeepCopy() *RotationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RotationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RotationStatus) DeepCopyInto(out *RotationStatus) {
+	*out = *in
+	if in.Variables != nil {
+		in, out := &in.Variables, &out.Variables
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Dependents != nil {
+		in, out := &in.Dependents, &out.Dependents
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]RotationStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RotationStatus.
+func (in *RotationStatus) DeepCopy() *RotationStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(DependencySpecs)
+	out := new(RotationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RotationStep) DeepCopyInto(out *RotationStep) {
+	*out = *in
+	if in.StartedAt != nil {
+		in, out := &in.StartedAt, &out.StartedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.FinishedAt != nil {
+		in, out := &in.FinishedAt, &out.FinishedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RotationStep.
+func (in *RotationStep) DeepCopy() *RotationStep {
+	if in == nil {
+		return nil
+	}
+	out := new(RotationStep)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -410,6 +1703,121 @@ func (in *SecretVariableSource) DeepCopy() *SecretVariableSource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StemcellDirectorStatus) DeepCopyInto(out *StemcellDirectorStatus) {
+	*out = *in
+	in.DirectorStatus.DeepCopyInto(&out.DirectorStatus)
+	if in.Uploaded != nil {
+		in, out := &in.Uploaded, &out.Uploaded
+		*out = new(UploadedStemcell)
+		**out = **in
+	}
+	if in.VerifiedAt != nil {
+		in, out := &in.VerifiedAt, &out.VerifiedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StemcellDirectorStatus.
+func (in *StemcellDirectorStatus) DeepCopy() *StemcellDirectorStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(StemcellDirectorStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StemcellRef) DeepCopyInto(out *StemcellRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StemcellRef.
+func (in *StemcellRef) DeepCopy() *StemcellRef {
+	if in == nil {
+		return nil
+	}
+	out := new(StemcellRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TaskStatus) DeepCopyInto(out *TaskStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskStatus.
+func (in *TaskStatus) DeepCopy() *TaskStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TaskStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeSpec) DeepCopyInto(out *UpgradeSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeSpec.
+func (in *UpgradeSpec) DeepCopy() *UpgradeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeStatus) DeepCopyInto(out *UpgradeStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeStatus.
+func (in *UpgradeStatus) DeepCopy() *UpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UploadedRelease) DeepCopyInto(out *UploadedRelease) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UploadedRelease.
+func (in *UploadedRelease) DeepCopy() *UploadedRelease {
+	if in == nil {
+		return nil
+	}
+	out := new(UploadedRelease)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UploadedStemcell) DeepCopyInto(out *UploadedStemcell) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UploadedStemcell.
+func (in *UploadedStemcell) DeepCopy() *UploadedStemcell {
+	if in == nil {
+		return nil
+	}
+	out := new(UploadedStemcell)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VariableSource) DeepCopyInto(out *VariableSource) {
 	*out = *in
@@ -0,0 +1,268 @@
+// Package artifacts is an HTTP cache for the (large) stemcell and release
+// tarballs that Gluon uploads to BOSH directors.  Artifacts are stored
+// by digest, fetched from upstream on first use, verified against that
+// digest, and evicted (least recently used first) once the cache grows
+// past its size limit.
+//
+// Artifacts live at /artifacts/<algorithm>/<digest>, where algorithm is
+// sha1 or sha256:
+//
+//     GET /artifacts/sha1/<digest>?url=<upstream>
+//
+// serves the artifact, fetching it from upstream first if need be, and
+//
+//     PUT /artifacts/sha1/<digest>
+//
+// seeds the cache with an artifact, for clusters that can't reach
+// upstream at all.
+package artifacts
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache is an artifact cache, backed by a directory.
+type Cache struct {
+	// Dir is where the artifacts are kept.
+	Dir string
+
+	// MaxSize is how big (in bytes) the cache may get before the least
+	// recently used artifacts are evicted.  Zero means no limit.
+	MaxSize int64
+
+	// Offline caches never fetch anything from upstream; they only
+	// serve what they have been seeded with.
+	Offline bool
+
+	HTTP *http.Client
+
+	mu      sync.Mutex
+	fetches map[string]*sync.Mutex
+}
+
+// New returns a Cache that keeps its artifacts in dir.
+func New(dir string, maxSize int64) *Cache {
+	return &Cache{
+		Dir:     dir,
+		MaxSize: maxSize,
+		HTTP:    &http.Client{Timeout: 2 * time.Hour},
+	}
+}
+
+// newHash returns a hash for the named digest algorithm.
+func newHash(algo string) (hash.Hash, error) {
+	switch algo {
+	case "sha1":
+		return sha1.New(), nil
+	case "sha256":
+		return sha256.New(), nil
+	}
+	return nil, fmt.Errorf("unsupported digest algorithm '%s'", algo)
+}
+
+// Path returns where the artifact with the given digest is kept.
+func (c *Cache) Path(algo, digest string) string {
+	return filepath.Join(c.Dir, algo, strings.ToLower(digest))
+}
+
+// ServeHTTP implements http.Handler.
+func (c *Cache) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/healthz" {
+		fmt.Fprintf(w, "ok\n")
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if len(parts) != 3 || parts[0] != "artifacts" {
+		http.NotFound(w, r)
+		return
+	}
+	algo, digest := parts[1], strings.ToLower(parts[2])
+	if _, err := newHash(algo); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, err := hex.DecodeString(digest); err != nil || digest == "" {
+		http.Error(w, "malformed digest", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		c.get(w, r, algo, digest)
+	case http.MethodPut:
+		if err := c.Store(algo, digest, r.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (c *Cache) get(w http.ResponseWriter, r *http.Request, algo, digest string) {
+	path := c.Path(algo, digest)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		upstream := r.URL.Query().Get("url")
+		if upstream == "" || c.Offline {
+			http.NotFound(w, r)
+			return
+		}
+		if err := c.Fetch(algo, digest, upstream); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		// evicted out from under us?
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer f.Close()
+
+	// serving an artifact counts as using it
+	now := time.Now()
+	os.Chtimes(path, now, now)
+
+	fi, err := f.Stat()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeContent(w, r, digest, fi.ModTime(), f)
+}
+
+// Fetch downloads an artifact from upstream into the cache, unless it is
+// already there (or already being downloaded by someone else).
+func (c *Cache) Fetch(algo, digest, upstream string) error {
+	lock := c.fetching(algo + "/" + digest)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if _, err := os.Stat(c.Path(algo, digest)); err == nil {
+		return nil
+	}
+
+	res, err := c.HTTP.Get(upstream)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching %s: %s", upstream, res.Status)
+	}
+	return c.Store(algo, digest, res.Body)
+}
+
+// fetching returns the lock held while downloading the given artifact.
+func (c *Cache) fetching(key string) *sync.Mutex {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.fetches == nil {
+		c.fetches = make(map[string]*sync.Mutex)
+	}
+	if _, ok := c.fetches[key]; !ok {
+		c.fetches[key] = &sync.Mutex{}
+	}
+	return c.fetches[key]
+}
+
+// Store puts an artifact into the cache, provided that its contents
+// match its digest, and then evicts whatever no longer fits.
+func (c *Cache) Store(algo, digest string, in io.Reader) error {
+	h, err := newHash(algo)
+	if err != nil {
+		return err
+	}
+	digest = strings.ToLower(digest)
+
+	dir := filepath.Join(c.Dir, algo)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(dir, ".incoming-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(io.MultiWriter(tmp, h), in)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != digest {
+		return fmt.Errorf("%s digest mismatch: expected %s, got %s", algo, digest, got)
+	}
+
+	if err := os.Rename(tmp.Name(), c.Path(algo, digest)); err != nil {
+		return err
+	}
+	return c.Evict(c.Path(algo, digest))
+}
+
+// Evict removes the least recently used artifacts until the cache fits
+// within MaxSize again.  The artifact at keep (if any) is never evicted.
+func (c *Cache) Evict(keep string) error {
+	if c.MaxSize <= 0 {
+		return nil
+	}
+
+	type artifact struct {
+		path string
+		size int64
+		used time.Time
+	}
+	var all []artifact
+	var total int64
+	err := filepath.Walk(c.Dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() || strings.HasPrefix(fi.Name(), ".") {
+			return nil
+		}
+		all = append(all, artifact{path: path, size: fi.Size(), used: fi.ModTime()})
+		total += fi.Size()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].used.Before(all[j].used)
+	})
+	for _, a := range all {
+		if total <= c.MaxSize {
+			break
+		}
+		if a.path == keep {
+			continue
+		}
+		if err := os.Remove(a.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		total -= a.size
+	}
+	return nil
+}
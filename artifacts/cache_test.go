@@ -0,0 +1,136 @@
+package artifacts_test
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/starkandwayne/gluon-controller/artifacts"
+)
+
+func sha1sum(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func sha256sum(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+var _ = Describe("Artifact Cache", func() {
+	var dir string
+	var cache *artifacts.Cache
+	var server, upstream *httptest.Server
+	var fetched int
+
+	get := func(path string) (int, string) {
+		res, err := http.Get(server.URL + path)
+		Expect(err).ToNot(HaveOccurred())
+		defer res.Body.Close()
+		b, err := ioutil.ReadAll(res.Body)
+		Expect(err).ToNot(HaveOccurred())
+		return res.StatusCode, string(b)
+	}
+
+	put := func(path, body string) int {
+		req, err := http.NewRequest(http.MethodPut, server.URL+path, strings.NewReader(body))
+		Expect(err).ToNot(HaveOccurred())
+		res, err := http.DefaultClient.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		res.Body.Close()
+		return res.StatusCode
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "artifacts")
+		Expect(err).ToNot(HaveOccurred())
+
+		fetched = 0
+		upstream = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fetched++
+			w.Write([]byte("stemcell " + strings.TrimPrefix(r.URL.Path, "/")))
+		}))
+		cache = artifacts.New(dir, 0)
+		server = httptest.NewServer(cache)
+	})
+
+	AfterEach(func() {
+		server.Close()
+		upstream.Close()
+		os.RemoveAll(dir)
+	})
+
+	It("fetches artifacts from upstream, once", func() {
+		digest := sha1sum("stemcell 1.18")
+		for i := 0; i < 2; i++ {
+			code, body := get("/artifacts/sha1/" + digest + "?url=" + upstream.URL + "/1.18")
+			Expect(code).To(Equal(200))
+			Expect(body).To(Equal("stemcell 1.18"))
+		}
+		Expect(fetched).To(Equal(1))
+		Expect(cache.Path("sha1", digest)).To(BeAnExistingFile())
+	})
+
+	It("verifies sha256 digests too", func() {
+		code, body := get("/artifacts/sha256/" + sha256sum("stemcell 2.1") + "?url=" + upstream.URL + "/2.1")
+		Expect(code).To(Equal(200))
+		Expect(body).To(Equal("stemcell 2.1"))
+	})
+
+	It("refuses artifacts that don't match their digest", func() {
+		digest := sha1sum("something else entirely")
+		code, _ := get("/artifacts/sha1/" + digest + "?url=" + upstream.URL + "/1.18")
+		Expect(code).To(Equal(http.StatusBadGateway))
+		Expect(cache.Path("sha1", digest)).ToNot(BeAnExistingFile())
+
+		Expect(put("/artifacts/sha1/"+digest, "stemcell 1.18")).To(Equal(http.StatusBadRequest))
+	})
+
+	It("serves seeded artifacts without going upstream", func() {
+		cache.Offline = true
+		digest := sha1sum("stemcell 1.18")
+		code, _ := get("/artifacts/sha1/" + digest + "?url=" + upstream.URL + "/1.18")
+		Expect(code).To(Equal(404))
+
+		Expect(put("/artifacts/sha1/"+digest, "stemcell 1.18")).To(Equal(http.StatusCreated))
+		code, body := get("/artifacts/sha1/" + digest + "?url=" + upstream.URL + "/1.18")
+		Expect(code).To(Equal(200))
+		Expect(body).To(Equal("stemcell 1.18"))
+		Expect(fetched).To(Equal(0))
+	})
+
+	It("rejects unknown digest algorithms", func() {
+		code, _ := get("/artifacts/md5/d41d8cd98f00b204e9800998ecf8427e")
+		Expect(code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("evicts the least recently used artifacts", func() {
+		cache.MaxSize = int64(2 * len("stemcell 1.18"))
+		old, older := sha1sum("stemcell 1.18"), sha1sum("stemcell 1.17")
+		Expect(put("/artifacts/sha1/"+older, "stemcell 1.17")).To(Equal(http.StatusCreated))
+		Expect(put("/artifacts/sha1/"+old, "stemcell 1.18")).To(Equal(http.StatusCreated))
+		then := time.Now().Add(-time.Hour)
+		Expect(os.Chtimes(cache.Path("sha1", older), then, then)).To(Succeed())
+
+		Expect(put("/artifacts/sha1/"+sha1sum("stemcell 1.19"), "stemcell 1.19")).To(Equal(http.StatusCreated))
+		Expect(cache.Path("sha1", older)).ToNot(BeAnExistingFile())
+		Expect(cache.Path("sha1", old)).To(BeAnExistingFile())
+		Expect(cache.Path("sha1", sha1sum("stemcell 1.19"))).To(BeAnExistingFile())
+
+		leftovers, err := filepath.Glob(filepath.Join(dir, "sha1", ".incoming-*"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(leftovers).To(BeEmpty())
+	})
+})
@@ -0,0 +1,84 @@
+package bosh
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// authorization returns the Authorization header value for the next
+// request, logging into UAA (or refreshing our token) as needed.
+func (c *Client) authorization() (string, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.auth == nil {
+		info, err := c.Info()
+		if err != nil {
+			return "", err
+		}
+		c.auth = &info.Auth
+	}
+
+	switch c.auth.Type {
+	case "basic":
+		creds := base64.StdEncoding.EncodeToString([]byte(c.username + ":" + c.password))
+		return "Basic " + creds, nil
+
+	case "uaa":
+		// refresh a little early, so tokens don't expire in flight
+		if c.token == "" || time.Now().After(c.expires.Add(-30*time.Second)) {
+			if err := c.login(); err != nil {
+				return "", err
+			}
+		}
+		return "Bearer " + c.token, nil
+	}
+
+	return "", fmt.Errorf("unsupported BOSH director authentication type '%s'", c.auth.Type)
+}
+
+// login gets a new access token from UAA, via the client credentials grant.
+func (c *Client) login() error {
+	uaa, ok := c.auth.Options["url"].(string)
+	if !ok || uaa == "" {
+		return fmt.Errorf("BOSH director uses UAA, but did not tell us where it is")
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	req, err := http.NewRequest("POST", strings.TrimSuffix(uaa, "/")+"/oauth/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(c.username), url.QueryEscape(c.password))
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != 200 {
+		res.Body.Close()
+		return fmt.Errorf("unable to authenticate to UAA at %s: HTTP %d", uaa, res.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := decode(res, &token); err != nil {
+		return err
+	}
+	if token.AccessToken == "" {
+		return fmt.Errorf("UAA at %s did not hand us an access token", uaa)
+	}
+
+	c.token = token.AccessToken
+	c.expires = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	return nil
+}
@@ -0,0 +1,172 @@
+// Package bosh is a (small) client for the BOSH Director REST API,
+// for the read-mostly things that aren't worth spinning up a pod
+// running the `bosh` CLI for.
+package bosh
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config tells the Client where the director is, and how to log into it.
+// For directors that use UAA, Username and Password are the UAA client
+// ID and secret (i.e. what you would set BOSH_CLIENT and
+// BOSH_CLIENT_SECRET to).
+type Config struct {
+	Endpoint string
+	Username string
+	Password string
+	CA       string
+
+	SkipSSLValidation bool
+	Timeout           time.Duration
+}
+
+// Client talks to a single BOSH director.
+type Client struct {
+	endpoint string
+	username string
+	password string
+	http     *http.Client
+
+	lock    sync.Mutex
+	auth    *Authentication
+	token   string
+	expires time.Time
+}
+
+// Error is what the director sends back when it is unhappy with us.
+type Error struct {
+	StatusCode  int    `json:"-"`
+	Code        int    `json:"code"`
+	Description string `json:"description"`
+}
+
+func (e Error) Error() string {
+	if e.Description == "" {
+		return fmt.Sprintf("BOSH director returned HTTP %d", e.StatusCode)
+	}
+	return fmt.Sprintf("BOSH director returned HTTP %d: %s (error %d)", e.StatusCode, e.Description, e.Code)
+}
+
+// New returns a Client for the director described by cfg.
+func New(cfg Config) (*Client, error) {
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("no BOSH director endpoint given")
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.SkipSSLValidation}
+	if cfg.CA != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(cfg.CA)) {
+			return nil, fmt.Errorf("unable to parse BOSH director CA certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+
+	return &Client{
+		endpoint: strings.TrimSuffix(cfg.Endpoint, "/"),
+		username: cfg.Username,
+		password: cfg.Password,
+		http: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: tlsConfig,
+			},
+			// the director answers anything that starts a task with a
+			// redirect to the task; we want the task, not its contents.
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}, nil
+}
+
+// Info returns what the director says about itself.  It does not
+// require authentication, so it is good for checking reachability.
+func (c *Client) Info() (Info, error) {
+	var info Info
+	res, err := c.do("GET", "/info", nil, "", false)
+	if err != nil {
+		return info, err
+	}
+	return info, decode(res, &info)
+}
+
+// request sends an authenticated request to the director.
+func (c *Client) request(method, path string, body io.Reader, contentType string) (*http.Response, error) {
+	return c.do(method, path, body, contentType, true)
+}
+
+func (c *Client) do(method, path string, body io.Reader, contentType string, authenticate bool) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.endpoint+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if authenticate {
+		header, err := c.authorization()
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", header)
+	}
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == 401 && authenticate {
+		// our token (or the way we authenticate) may be out of date
+		// (the director was redeployed, say); start over next time.
+		c.lock.Lock()
+		c.auth, c.token = nil, ""
+		c.lock.Unlock()
+	}
+	if res.StatusCode >= 400 {
+		defer res.Body.Close()
+		e := Error{StatusCode: res.StatusCode}
+		if b, err := ioutil.ReadAll(res.Body); err == nil {
+			json.Unmarshal(b, &e)
+		}
+		return nil, e
+	}
+	return res, nil
+}
+
+// get retrieves path from the director, and decodes the JSON response into out.
+func (c *Client) get(path string, out interface{}) error {
+	res, err := c.request("GET", path, nil, "")
+	if err != nil {
+		return err
+	}
+	return decode(res, out)
+}
+
+func decode(res *http.Response, out interface{}) error {
+	defer res.Body.Close()
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, out); err != nil {
+		return fmt.Errorf("unable to parse BOSH director response: %s", err)
+	}
+	return nil
+}
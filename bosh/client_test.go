@@ -0,0 +1,282 @@
+package bosh_test
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/starkandwayne/gluon-controller/bosh"
+)
+
+var _ = Describe("BOSH Director Client", func() {
+	var director *fakeDirector
+
+	AfterEach(func() {
+		if director != nil {
+			director.Close()
+			director = nil
+		}
+	})
+
+	Context("when creating a client", func() {
+		It("requires an endpoint", func() {
+			_, err := bosh.New(bosh.Config{})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("rejects CA certificates that aren't", func() {
+			_, err := bosh.New(bosh.Config{Endpoint: "https://10.0.0.6:25555", CA: "not a certificate"})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("trusts the given CA certificate", func() {
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				respond(w, 200, map[string]interface{}{"name": "secure"})
+			}))
+			defer server.Close()
+
+			c, err := bosh.New(bosh.Config{Endpoint: server.URL})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = c.Info()
+			Expect(err).To(HaveOccurred())
+
+			ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+			c, err = bosh.New(bosh.Config{Endpoint: server.URL, CA: string(ca)})
+			Expect(err).ToNot(HaveOccurred())
+			info, err := c.Info()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Name).To(Equal("secure"))
+		})
+	})
+
+	Context("with basic authentication", func() {
+		BeforeEach(func() {
+			director = newFakeDirector("basic")
+			director.Deployments = []bosh.Deployment{
+				{Name: "cf", Stemcells: []bosh.NameVersion{{Name: "ubuntu-xenial", Version: "621.64"}}},
+				{Name: "redis"},
+			}
+		})
+
+		It("retrieves director info without logging in", func() {
+			info, err := director.Client().Info()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Name).To(Equal("fake"))
+			Expect(info.Version).To(Equal("271.2.0 (00000000)"))
+			Expect(info.Auth.Type).To(Equal("basic"))
+		})
+
+		It("lists deployments", func() {
+			l, err := director.Client().Deployments()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(l).To(HaveLen(2))
+			Expect(l[0].Name).To(Equal("cf"))
+			Expect(l[0].Stemcells[0].Version).To(Equal("621.64"))
+		})
+
+		It("reports authentication failures", func() {
+			c, err := bosh.New(bosh.Config{Endpoint: director.URL, Username: "admin", Password: "wrong"})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = c.Deployments()
+			Expect(err).To(HaveOccurred())
+
+			e, ok := err.(bosh.Error)
+			Expect(ok).To(BeTrue())
+			Expect(e.StatusCode).To(Equal(401))
+			Expect(e.Description).To(Equal("Not authorized"))
+		})
+	})
+
+	Context("with UAA authentication", func() {
+		BeforeEach(func() {
+			director = newFakeDirector("uaa")
+		})
+
+		It("logs into UAA once, and reuses the token", func() {
+			c := director.Client()
+			_, err := c.Deployments()
+			Expect(err).ToNot(HaveOccurred())
+			_, err = c.Stemcells()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(director.TokensIssued()).To(Equal(1))
+		})
+
+		It("logs back in when the token is about to expire", func() {
+			director.ExpiresIn = 10
+			c := director.Client()
+			_, err := c.Deployments()
+			Expect(err).ToNot(HaveOccurred())
+			_, err = c.Stemcells()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(director.TokensIssued()).To(Equal(2))
+		})
+
+		It("logs back in after the director stops taking its token", func() {
+			c := director.Client()
+			_, err := c.Deployments()
+			Expect(err).ToNot(HaveOccurred())
+
+			director.RevokeTokens()
+			_, err = c.Deployments()
+			Expect(err).To(HaveOccurred())
+			_, err = c.Deployments()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(director.TokensIssued()).To(Equal(2))
+		})
+
+		It("fails if UAA won't have us", func() {
+			c, err := bosh.New(bosh.Config{Endpoint: director.URL, Username: "admin", Password: "wrong"})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = c.Deployments()
+			Expect(err).To(HaveOccurred())
+			Expect(director.TokensIssued()).To(Equal(0))
+		})
+	})
+
+	Context("with things on the director", func() {
+		BeforeEach(func() {
+			director = newFakeDirector("basic")
+			director.Stemcells = []bosh.Stemcell{
+				{Name: "bosh-warden-boshlite-ubuntu-xenial-go_agent", Version: "621.64", OperatingSystem: "ubuntu-xenial"},
+			}
+			director.Releases = []bosh.Release{
+				{Name: "redis", Versions: []bosh.ReleaseVersion{{Version: "15.0.0", CurrentlyDeployed: true}, {Version: "14.0.1"}}},
+			}
+			director.Locks = []bosh.Lock{
+				{Type: "deployment", Resource: []string{"cf"}, TaskID: "13"},
+			}
+			director.Tasks = []bosh.Task{
+				{ID: 13, State: bosh.TaskProcessing, Deployment: "cf", Description: "create deployment"},
+				{ID: 12, State: bosh.TaskQueued, Deployment: "redis", Description: "create deployment"},
+				{ID: 11, State: bosh.TaskDone, Deployment: "cf", Description: "create deployment", Result: "/deployments/cf"},
+				{ID: 10, State: bosh.TaskError, Deployment: "redis", Description: "create deployment"},
+			}
+			director.Output[11] = `{"name":"cf"}` + "\n"
+			director.Configs = []bosh.DirectorConfig{
+				{ID: "3", Type: "cloud", Name: "default", Content: "azs: []\n", Current: true},
+				{ID: "4", Type: "runtime", Name: "dns", Content: "addons: []\n", Current: true},
+			}
+			director.Events = []bosh.Event{
+				{ID: "40", Action: "create", ObjectType: "deployment", ObjectName: "cf", Deployment: "cf", Task: "13"},
+				{ID: "39", Action: "update", ObjectType: "deployment", ObjectName: "redis", Deployment: "redis", Task: "12"},
+				{ID: "38", Action: "create", ObjectType: "deployment", ObjectName: "cf", Deployment: "cf", Task: "11"},
+			}
+		})
+
+		It("lists stemcells and releases", func() {
+			c := director.Client()
+			stemcells, err := c.Stemcells()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stemcells).To(HaveLen(1))
+			Expect(stemcells[0].OperatingSystem).To(Equal("ubuntu-xenial"))
+
+			releases, err := c.Releases()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(releases).To(HaveLen(1))
+			Expect(releases[0].Versions).To(HaveLen(2))
+			Expect(releases[0].Versions[0].CurrentlyDeployed).To(BeTrue())
+		})
+
+		It("finds deployment locks", func() {
+			c := director.Client()
+			lock, err := c.DeploymentLock("cf")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lock).ToNot(BeNil())
+			Expect(lock.TaskID).To(Equal("13"))
+
+			lock, err = c.DeploymentLock("redis")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lock).To(BeNil())
+		})
+
+		It("lists current tasks", func() {
+			l, err := director.Client().CurrentTasks()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(l).To(HaveLen(2))
+			Expect(l[0].ID).To(Equal(13))
+			Expect(l[0].Finished()).To(BeFalse())
+			Expect(l[1].ID).To(Equal(12))
+		})
+
+		It("lists recent tasks for a deployment", func() {
+			l, err := director.Client().RecentTasks("redis", 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(l).To(HaveLen(1))
+			Expect(l[0].ID).To(Equal(12))
+			Expect(director.Requests).To(ContainElement("GET /tasks?deployment=redis&limit=1&verbose=1"))
+		})
+
+		It("retrieves tasks, and their output", func() {
+			c := director.Client()
+			t, err := c.Task(11)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(t.State).To(Equal(bosh.TaskDone))
+			Expect(t.Finished()).To(BeTrue())
+			Expect(t.Result).To(Equal("/deployments/cf"))
+
+			out, err := c.TaskOutput(11, "result")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal(`{"name":"cf"}` + "\n"))
+		})
+
+		It("parses task events", func() {
+			director.EventOutput[13] = `{"time":1591014896,"stage":"Preparing deployment","tags":[],"total":1,"task":"Preparing deployment","index":1,"state":"started","progress":0}
+{"time":1591014897,"stage":"Updating instance","tags":["diego-cell"],"total":20,"task":"diego-cell/4e1c (0) (canary)","index":3,"state":"failed","progress":100,"data":{"error":"'diego-cell/4e1c (0)' is not running after update"}}
+this is not json
+{"time":1591014898,"error":{"code":400007,"message":"'diego-cell/4e1c (0)' is not running after update"}}
+`
+			l, err := director.Client().TaskEvents(13)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(l).To(HaveLen(3))
+			Expect(l[1].Stage).To(Equal("Updating instance"))
+			Expect(l[1].Tags).To(Equal([]string{"diego-cell"}))
+			Expect(l[1].Index).To(Equal(3))
+			Expect(l[1].Total).To(Equal(20))
+			Expect(l[1].Data.Error).To(ContainSubstring("is not running"))
+			Expect(l[2].Error).ToNot(BeNil())
+			Expect(l[2].Error.Code).To(Equal(400007))
+		})
+
+		It("reports missing tasks", func() {
+			_, err := director.Client().Task(99)
+			Expect(err).To(HaveOccurred())
+			e, ok := err.(bosh.Error)
+			Expect(ok).To(BeTrue())
+			Expect(e.StatusCode).To(Equal(404))
+			Expect(e.Code).To(Equal(10001))
+		})
+
+		It("cancels tasks", func() {
+			c := director.Client()
+			Expect(c.CancelTask(13)).To(Succeed())
+			t, err := c.Task(13)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(t.State).To(Equal(bosh.TaskCancelling))
+			Expect(director.Requests).To(ContainElement("DELETE /task/13"))
+		})
+
+		It("lists configs", func() {
+			l, err := director.Client().Configs("runtime", "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(l).To(HaveLen(1))
+			Expect(l[0].Name).To(Equal("dns"))
+			Expect(director.Requests).To(ContainElement("GET /configs?latest=true&type=runtime"))
+		})
+
+		It("lists events", func() {
+			c := director.Client()
+			l, err := c.Events(bosh.EventFilter{Deployment: "cf"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(l).To(HaveLen(2))
+			Expect(l[0].Task).To(Equal("13"))
+
+			l, err = c.Events(bosh.EventFilter{BeforeID: "40"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(l).To(HaveLen(2))
+			Expect(l[0].ID).To(Equal("39"))
+		})
+	})
+})
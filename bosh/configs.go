@@ -0,0 +1,34 @@
+package bosh
+
+import (
+	"net/url"
+)
+
+// DirectorConfig is a cloud, runtime, cpi (or other) config on the
+// director.  (Not to be confused with Config, which configures Clients.)
+type DirectorConfig struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Content   string `json:"content"`
+	CreatedAt string `json:"created_at"`
+	Team      string `json:"team"`
+	Current   bool   `json:"current"`
+}
+
+// Configs lists the latest version of each config on the director.
+// If typ (or name) is given, only configs of that type (or name) are
+// listed.
+func (c *Client) Configs(typ, name string) ([]DirectorConfig, error) {
+	q := url.Values{}
+	q.Set("latest", "true")
+	if typ != "" {
+		q.Set("type", typ)
+	}
+	if name != "" {
+		q.Set("name", name)
+	}
+
+	var l []DirectorConfig
+	return l, c.get("/configs?"+q.Encode(), &l)
+}
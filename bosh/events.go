@@ -0,0 +1,75 @@
+package bosh
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Event is an entry in the director's audit log.
+type Event struct {
+	ID         string                 `json:"id"`
+	ParentID   string                 `json:"parent_id"`
+	Timestamp  int64                  `json:"timestamp"`
+	User       string                 `json:"user"`
+	Action     string                 `json:"action"`
+	ObjectType string                 `json:"object_type"`
+	ObjectName string                 `json:"object_name"`
+	Task       string                 `json:"task"`
+	Deployment string                 `json:"deployment"`
+	Instance   string                 `json:"instance"`
+	Context    map[string]interface{} `json:"context"`
+	Error      string                 `json:"error"`
+}
+
+// EventFilter narrows down which events get listed.  Anything left
+// blank (or zero) is not filtered on.
+type EventFilter struct {
+	// BeforeID pages backwards through the events; only events
+	// older than the one with this ID are listed.
+	BeforeID string
+
+	Before time.Time
+	After  time.Time
+
+	Deployment string
+	Task       string
+	Instance   string
+	User       string
+	Action     string
+	ObjectType string
+	ObjectName string
+}
+
+// Events lists (up to 200 of) the most recent events on the director,
+// newest first.
+func (c *Client) Events(filter EventFilter) ([]Event, error) {
+	q := url.Values{}
+	set := func(k, v string) {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	set("before_id", filter.BeforeID)
+	set("deployment", filter.Deployment)
+	set("task", filter.Task)
+	set("instance", filter.Instance)
+	set("user", filter.User)
+	set("action", filter.Action)
+	set("object_type", filter.ObjectType)
+	set("object_name", filter.ObjectName)
+	if !filter.Before.IsZero() {
+		q.Set("before_time", strconv.FormatInt(filter.Before.Unix(), 10))
+	}
+	if !filter.After.IsZero() {
+		q.Set("after_time", strconv.FormatInt(filter.After.Unix(), 10))
+	}
+
+	path := "/events"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+
+	var l []Event
+	return l, c.get(path, &l)
+}
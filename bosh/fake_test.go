@@ -0,0 +1,255 @@
+package bosh_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/starkandwayne/gluon-controller/bosh"
+)
+
+// fakeDirector is just enough of a BOSH director (and its UAA) to test
+// the client against, in-process.
+type fakeDirector struct {
+	*httptest.Server
+	UAA *httptest.Server
+
+	// Auth is either "basic" or "uaa"
+	Auth      string
+	Username  string
+	Password  string
+	ExpiresIn int
+
+	Deployments []bosh.Deployment
+	Stemcells   []bosh.Stemcell
+	Releases    []bosh.Release
+	Locks       []bosh.Lock
+	Tasks       []bosh.Task
+	Configs     []bosh.DirectorConfig
+	Events      []bosh.Event
+	Output      map[int]string
+	EventOutput map[int]string
+
+	lock     sync.Mutex
+	tokens   int
+	revoked  int
+	Requests []string
+}
+
+func newFakeDirector(auth string) *fakeDirector {
+	d := &fakeDirector{
+		Auth:        auth,
+		Username:    "admin",
+		Password:    "sekrit",
+		ExpiresIn:   3600,
+		Output:      make(map[int]string),
+		EventOutput: make(map[int]string),
+	}
+	d.Server = httptest.NewServer(http.HandlerFunc(d.serve))
+	d.UAA = httptest.NewServer(http.HandlerFunc(d.token))
+	return d
+}
+
+func (d *fakeDirector) Close() {
+	d.Server.Close()
+	d.UAA.Close()
+}
+
+func (d *fakeDirector) Client() *bosh.Client {
+	c, err := bosh.New(bosh.Config{
+		Endpoint: d.URL,
+		Username: d.Username,
+		Password: d.Password,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// RevokeTokens invalidates every token UAA has issued so far.
+func (d *fakeDirector) RevokeTokens() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.revoked = d.tokens
+}
+
+// TokensIssued is how many times the client has logged into UAA.
+func (d *fakeDirector) TokensIssued() int {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.tokens
+}
+
+func (d *fakeDirector) token(w http.ResponseWriter, r *http.Request) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	user, pass, ok := r.BasicAuth()
+	if r.URL.Path != "/oauth/token" || r.FormValue("grant_type") != "client_credentials" {
+		w.WriteHeader(400)
+		return
+	}
+	if !ok || user != d.Username || pass != d.Password {
+		w.WriteHeader(401)
+		return
+	}
+
+	d.tokens++
+	respond(w, 200, map[string]interface{}{
+		"access_token": fmt.Sprintf("token-%d", d.tokens),
+		"token_type":   "bearer",
+		"expires_in":   d.ExpiresIn,
+	})
+}
+
+func (d *fakeDirector) authorized(r *http.Request) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.Auth == "uaa" {
+		return d.tokens > d.revoked && r.Header.Get("Authorization") == fmt.Sprintf("Bearer token-%d", d.tokens)
+	}
+	user, pass, ok := r.BasicAuth()
+	return ok && user == d.Username && pass == d.Password
+}
+
+func (d *fakeDirector) serve(w http.ResponseWriter, r *http.Request) {
+	d.lock.Lock()
+	d.Requests = append(d.Requests, r.Method+" "+r.URL.RequestURI())
+	d.lock.Unlock()
+
+	if r.URL.Path == "/info" {
+		info := map[string]interface{}{
+			"name":    "fake",
+			"uuid":    "00000000-0000-0000-0000-000000000000",
+			"version": "271.2.0 (00000000)",
+			"cpi":     "warden_cpi",
+			"user_authentication": map[string]interface{}{
+				"type":    "basic",
+				"options": map[string]interface{}{},
+			},
+		}
+		if d.Auth == "uaa" {
+			info["user_authentication"] = map[string]interface{}{
+				"type":    "uaa",
+				"options": map[string]interface{}{"url": d.UAA.URL},
+			}
+		}
+		respond(w, 200, info)
+		return
+	}
+
+	if !d.authorized(r) {
+		respond(w, 401, map[string]interface{}{"code": 600000, "description": "Not authorized"})
+		return
+	}
+
+	q := r.URL.Query()
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/deployments":
+		respond(w, 200, d.Deployments)
+
+	case r.Method == "GET" && r.URL.Path == "/stemcells":
+		respond(w, 200, d.Stemcells)
+
+	case r.Method == "GET" && r.URL.Path == "/releases":
+		respond(w, 200, d.Releases)
+
+	case r.Method == "GET" && r.URL.Path == "/locks":
+		respond(w, 200, d.Locks)
+
+	case r.Method == "GET" && r.URL.Path == "/configs":
+		l := []bosh.DirectorConfig{}
+		for _, c := range d.Configs {
+			if (q.Get("type") == "" || q.Get("type") == c.Type) && (q.Get("name") == "" || q.Get("name") == c.Name) {
+				l = append(l, c)
+			}
+		}
+		respond(w, 200, l)
+
+	case r.Method == "GET" && r.URL.Path == "/events":
+		l := []bosh.Event{}
+		before := q.Get("before_id") == ""
+		for _, e := range d.Events {
+			if !before {
+				before = e.ID == q.Get("before_id")
+				continue
+			}
+			if q.Get("deployment") == "" || q.Get("deployment") == e.Deployment {
+				l = append(l, e)
+			}
+		}
+		respond(w, 200, l)
+
+	case r.Method == "GET" && r.URL.Path == "/tasks":
+		states := map[string]bool{}
+		if q.Get("state") != "" {
+			for _, s := range strings.Split(q.Get("state"), ",") {
+				states[s] = true
+			}
+		}
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		l := []bosh.Task{}
+		for _, t := range d.Tasks {
+			if limit > 0 && len(l) >= limit {
+				break
+			}
+			if (len(states) == 0 || states[t.State]) && (q.Get("deployment") == "" || q.Get("deployment") == t.Deployment) {
+				l = append(l, t)
+			}
+		}
+		respond(w, 200, l)
+
+	case r.Method == "GET" && len(parts) == 2 && parts[0] == "tasks":
+		if t := d.task(parts[1]); t != nil {
+			respond(w, 200, t)
+			return
+		}
+		respond(w, 404, map[string]interface{}{"code": 10001, "description": "Task " + parts[1] + " could not be found"})
+
+	case r.Method == "GET" && len(parts) == 3 && parts[0] == "tasks" && parts[2] == "output":
+		if t := d.task(parts[1]); t != nil && q.Get("type") == "result" {
+			w.WriteHeader(200)
+			fmt.Fprint(w, d.Output[t.ID])
+			return
+		}
+		if t := d.task(parts[1]); t != nil && q.Get("type") == "event" {
+			w.WriteHeader(200)
+			fmt.Fprint(w, d.EventOutput[t.ID])
+			return
+		}
+		w.WriteHeader(204)
+
+	case r.Method == "DELETE" && len(parts) == 2 && parts[0] == "task":
+		if t := d.task(parts[1]); t != nil {
+			t.State = bosh.TaskCancelling
+			w.WriteHeader(204)
+			return
+		}
+		respond(w, 404, map[string]interface{}{"code": 10001, "description": "Task " + parts[1] + " could not be found"})
+
+	default:
+		respond(w, 404, map[string]interface{}{"code": 0, "description": "no such endpoint"})
+	}
+}
+
+func (d *fakeDirector) task(id string) *bosh.Task {
+	for i := range d.Tasks {
+		if strconv.Itoa(d.Tasks[i].ID) == id {
+			return &d.Tasks[i]
+		}
+	}
+	return nil
+}
+
+func respond(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
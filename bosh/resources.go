@@ -0,0 +1,99 @@
+package bosh
+
+// Info is what the director says about itself, via GET /info
+type Info struct {
+	Name    string         `json:"name"`
+	UUID    string         `json:"uuid"`
+	Version string         `json:"version"`
+	User    string         `json:"user"`
+	CPI     string         `json:"cpi"`
+	Auth    Authentication `json:"user_authentication"`
+}
+
+// Authentication tells us how to log into the director.
+type Authentication struct {
+	Type    string                 `json:"type"`
+	Options map[string]interface{} `json:"options"`
+}
+
+type NameVersion struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type Deployment struct {
+	Name        string        `json:"name"`
+	CloudConfig string        `json:"cloud_config"`
+	Releases    []NameVersion `json:"releases"`
+	Stemcells   []NameVersion `json:"stemcells"`
+	Teams       []string      `json:"teams"`
+}
+
+type Stemcell struct {
+	Name            string `json:"name"`
+	OperatingSystem string `json:"operating_system"`
+	Version         string `json:"version"`
+	CID             string `json:"cid"`
+	CPI             string `json:"cpi"`
+	Deployments     []struct {
+		Name string `json:"name"`
+	} `json:"deployments"`
+}
+
+type Release struct {
+	Name     string           `json:"name"`
+	Versions []ReleaseVersion `json:"release_versions"`
+}
+
+type ReleaseVersion struct {
+	Version           string `json:"version"`
+	CommitHash        string `json:"commit_hash"`
+	CurrentlyDeployed bool   `json:"currently_deployed"`
+}
+
+// Lock is held by a director task while it works on a deployment
+// (or a release, or a compilation...)
+type Lock struct {
+	Type     string   `json:"type"`
+	Resource []string `json:"resource"`
+	Timeout  string   `json:"timeout"`
+	TaskID   string   `json:"task_id"`
+}
+
+// Deployments lists all of the deployments on the director.
+func (c *Client) Deployments() ([]Deployment, error) {
+	var l []Deployment
+	return l, c.get("/deployments", &l)
+}
+
+// Stemcells lists all of the stemcells uploaded to the director.
+func (c *Client) Stemcells() ([]Stemcell, error) {
+	var l []Stemcell
+	return l, c.get("/stemcells", &l)
+}
+
+// Releases lists all of the releases uploaded to the director.
+func (c *Client) Releases() ([]Release, error) {
+	var l []Release
+	return l, c.get("/releases", &l)
+}
+
+// Locks lists the locks currently held on the director.
+func (c *Client) Locks() ([]Lock, error) {
+	var l []Lock
+	return l, c.get("/locks", &l)
+}
+
+// DeploymentLock returns the lock held on a deployment, if there is one.
+func (c *Client) DeploymentLock(deployment string) (*Lock, error) {
+	locks, err := c.Locks()
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range locks {
+		if l.Type == "deployment" && len(l.Resource) > 0 && l.Resource[0] == deployment {
+			return &l, nil
+		}
+	}
+	return nil, nil
+}
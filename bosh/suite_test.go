@@ -0,0 +1,13 @@
+package bosh_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestBOSH(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "BOSH Director Client Suite")
+}
@@ -0,0 +1,139 @@
+package bosh
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	TaskQueued     = "queued"
+	TaskProcessing = "processing"
+	TaskCancelling = "cancelling"
+	TaskCancelled  = "cancelled"
+	TaskDone       = "done"
+	TaskError      = "error"
+	TaskTimeout    = "timeout"
+)
+
+type Task struct {
+	ID          int    `json:"id"`
+	State       string `json:"state"`
+	Description string `json:"description"`
+	Timestamp   int64  `json:"timestamp"`
+	StartedAt   int64  `json:"started_at"`
+	Result      string `json:"result"`
+	User        string `json:"user"`
+	Deployment  string `json:"deployment"`
+	ContextID   string `json:"context_id"`
+}
+
+// TaskEvent is a single line of a task's event output.  Most mark the
+// start (or end, or failure) of a step in some stage of the task; the
+// last one of a failed task usually carries the error instead.
+type TaskEvent struct {
+	Time     int64    `json:"time"`
+	Stage    string   `json:"stage"`
+	Tags     []string `json:"tags"`
+	Total    int      `json:"total"`
+	Task     string   `json:"task"`
+	Index    int      `json:"index"`
+	State    string   `json:"state"`
+	Progress int      `json:"progress"`
+
+	Data struct {
+		Error string `json:"error"`
+	} `json:"data"`
+
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Finished returns true if the task is not going to do anything else.
+func (t Task) Finished() bool {
+	switch t.State {
+	case TaskQueued, TaskProcessing, TaskCancelling:
+		return false
+	}
+	return true
+}
+
+// Task retrieves a single task.
+func (c *Client) Task(id int) (Task, error) {
+	var t Task
+	return t, c.get(fmt.Sprintf("/tasks/%d", id), &t)
+}
+
+// CurrentTasks lists the tasks that are queued, running, or on their way
+// to being cancelled, across all deployments.
+func (c *Client) CurrentTasks() ([]Task, error) {
+	var l []Task
+	return l, c.get("/tasks?state=queued,processing,cancelling&verbose=2", &l)
+}
+
+// RecentTasks lists the most recent tasks (up to limit of them) for a
+// deployment, newest first.  If deployment is blank, tasks for all
+// deployments are listed.
+func (c *Client) RecentTasks(deployment string, limit int) ([]Task, error) {
+	q := url.Values{}
+	q.Set("limit", strconv.Itoa(limit))
+	q.Set("verbose", "1")
+	if deployment != "" {
+		q.Set("deployment", deployment)
+	}
+
+	var l []Task
+	return l, c.get("/tasks?"+q.Encode(), &l)
+}
+
+// TaskOutput retrieves the output of a task; typ is one of "result",
+// "event", "debug" or "cpi".
+func (c *Client) TaskOutput(id int, typ string) (string, error) {
+	res, err := c.request("GET", fmt.Sprintf("/tasks/%d/output?type=%s", id, url.QueryEscape(typ)), nil, "")
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	b, err := ioutil.ReadAll(res.Body)
+	return string(b), err
+}
+
+// CancelTask asks the director to cancel a task.  Cancellation is not
+// immediate; the task goes into the "cancelling" state until it gets
+// to a point where it can stop.
+func (c *Client) CancelTask(id int) error {
+	res, err := c.request("DELETE", fmt.Sprintf("/task/%d", id), nil, "")
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+// TaskEvents retrieves (and parses) the event output of a task, oldest
+// first.  Lines that don't parse are skipped.
+func (c *Client) TaskEvents(id int) ([]TaskEvent, error) {
+	out, err := c.TaskOutput(id, "event")
+	if err != nil {
+		return nil, err
+	}
+
+	var l []TaskEvent
+	for _, line := range strings.Split(out, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var e TaskEvent
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			continue
+		}
+		l = append(l, e)
+	}
+	return l, nil
+}
@@ -0,0 +1,190 @@
+// Package boshio resolves stemcells (by operating system, version line
+// and IaaS) into concrete URLs and checksums, using the bosh.io stemcell
+// index, or a mirror of it.
+package boshio
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultIndex is the bosh.io stemcell index.
+const DefaultIndex = "https://bosh.io/api/v1/stemcells"
+
+// Index is a stemcell index; bosh.io, or anything that serves the same
+// JSON documents at <URL>/<stemcell-name> (a plain old file server will
+// do).
+type Index struct {
+	URL  string
+	HTTP *http.Client
+}
+
+// New returns an Index that reads from the given URL (or from bosh.io,
+// if url is empty).
+func New(url string) *Index {
+	if url == "" {
+		url = DefaultIndex
+	}
+	return &Index{
+		URL:  strings.TrimSuffix(url, "/"),
+		HTTP: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Tarball is one flavor (regular, or light) of a stemcell version.
+type Tarball struct {
+	URL    string `json:"url"`
+	Size   int64  `json:"size"`
+	MD5    string `json:"md5"`
+	SHA1   string `json:"sha1"`
+	SHA256 string `json:"sha256"`
+}
+
+// Version is a single version of a stemcell, as listed in the index.
+type Version struct {
+	Name    string   `json:"name"`
+	Version string   `json:"version"`
+	Regular *Tarball `json:"regular"`
+	Light   *Tarball `json:"light"`
+}
+
+// Stemcell is what a query resolves to.
+type Stemcell struct {
+	Name    string
+	Version string
+	Tarball
+}
+
+// Query asks for a stemcell by operating system (i.e. ubuntu-jammy),
+// version line and IaaS.  Version is an exact version (i.e. "1.18"),
+// a version line (i.e. "1.*"), or "latest" (or empty).  Light asks for
+// a light stemcell, which only some IaaSes have.
+type Query struct {
+	OS      string
+	Version string
+	IaaS    string
+	Light   bool
+}
+
+// the hypervisor (and so, the stemcell name) that goes with each IaaS
+var infrastructures = map[string]string{
+	"alicloud":  "alicloud-kvm",
+	"aws":       "aws-xen-hvm",
+	"azure":     "azure-hyperv",
+	"google":    "google-kvm",
+	"openstack": "openstack-kvm",
+	"softlayer": "softlayer-xen",
+	"vcloud":    "vcloud-esxi",
+	"vsphere":   "vsphere-esxi",
+	"warden":    "warden-boshlite",
+}
+
+// StemcellName returns the full name of the stemcell for an operating
+// system on an IaaS (i.e. bosh-aws-xen-hvm-ubuntu-jammy-go_agent).  The
+// IaaS can be given with its hypervisor (i.e. aws-xen-hvm), or without.
+func StemcellName(os, iaas string) string {
+	if hv, ok := infrastructures[iaas]; ok {
+		iaas = hv
+	}
+	return fmt.Sprintf("bosh-%s-%s-go_agent", iaas, os)
+}
+
+// Versions lists the versions of the named stemcell in the index, newest
+// first.
+func (i *Index) Versions(name string) ([]Version, error) {
+	res, err := i.HTTP.Get(fmt.Sprintf("%s/%s", i.URL, name))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return nil, fmt.Errorf("stemcell index %s returned %s for %s", i.URL, res.Status, name)
+	}
+
+	var l []Version
+	if err := json.NewDecoder(res.Body).Decode(&l); err != nil {
+		return nil, fmt.Errorf("unable to parse stemcell index entry for %s: %s", name, err)
+	}
+	sort.SliceStable(l, func(a, b int) bool {
+		return Compare(l[a].Version, l[b].Version) > 0
+	})
+	return l, nil
+}
+
+// Resolve finds the newest stemcell in the index that satisfies q.
+func (i *Index) Resolve(q Query) (Stemcell, error) {
+	if q.OS == "" || q.IaaS == "" {
+		return Stemcell{}, fmt.Errorf("both an operating system and an IaaS are required to resolve a stemcell")
+	}
+
+	name := StemcellName(q.OS, q.IaaS)
+	versions, err := i.Versions(name)
+	if err != nil {
+		return Stemcell{}, err
+	}
+	for _, v := range versions {
+		if !Matches(q.Version, v.Version) {
+			continue
+		}
+		t := v.Regular
+		if q.Light {
+			t = v.Light
+		}
+		if t == nil || t.URL == "" {
+			continue
+		}
+		return Stemcell{Name: name, Version: v.Version, Tarball: *t}, nil
+	}
+
+	flavor := ""
+	if q.Light {
+		flavor = "light "
+	}
+	return Stemcell{}, fmt.Errorf("no %sstemcell %s matching version '%s' found in %s", flavor, name, q.Version, i.URL)
+}
+
+// Matches returns true if version satisfies want, which is an exact
+// version, a version line (i.e. "1.*"), or "latest" (or empty).
+func Matches(want, version string) bool {
+	switch {
+	case want == "" || want == "latest" || want == "*":
+		return true
+	case strings.HasSuffix(want, ".*"):
+		return strings.HasPrefix(version, strings.TrimSuffix(want, "*"))
+	default:
+		return version == want
+	}
+}
+
+// Compare compares two stemcell versions, component by component,
+// numerically where it can.  It returns -1, 0 or 1, à la strings.Compare.
+func Compare(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for n := 0; n < len(as) || n < len(bs); n++ {
+		if n >= len(as) {
+			return -1
+		}
+		if n >= len(bs) {
+			return 1
+		}
+		x, errx := strconv.Atoi(as[n])
+		y, erry := strconv.Atoi(bs[n])
+		if errx != nil || erry != nil {
+			if c := strings.Compare(as[n], bs[n]); c != 0 {
+				return c
+			}
+			continue
+		}
+		if x < y {
+			return -1
+		} else if x > y {
+			return 1
+		}
+	}
+	return 0
+}
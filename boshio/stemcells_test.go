@@ -0,0 +1,92 @@
+package boshio_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/starkandwayne/gluon-controller/boshio"
+)
+
+// what bosh.io says about bosh-aws-xen-hvm-ubuntu-jammy-go_agent, more or less
+const jammy = `[
+  {"name":"bosh-aws-xen-hvm-ubuntu-jammy-go_agent","version":"1.9",
+   "regular":{"url":"https://example.com/1.9.tgz","sha1":"aaa9","sha256":"bbb9"}},
+  {"name":"bosh-aws-xen-hvm-ubuntu-jammy-go_agent","version":"1.18",
+   "regular":{"url":"https://example.com/1.18.tgz","sha1":"aaa18","sha256":"bbb18"},
+   "light":{"url":"https://example.com/light-1.18.tgz","sha1":"lll18"}},
+  {"name":"bosh-aws-xen-hvm-ubuntu-jammy-go_agent","version":"2.1",
+   "regular":{"url":"https://example.com/2.1.tgz","sha1":"aaa21","sha256":"bbb21"}}
+]`
+
+var _ = Describe("bosh.io Stemcell Index", func() {
+	var server *httptest.Server
+	var index *boshio.Index
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/stemcells/bosh-aws-xen-hvm-ubuntu-jammy-go_agent" {
+				w.WriteHeader(404)
+				return
+			}
+			w.Write([]byte(jammy))
+		}))
+		index = boshio.New(server.URL + "/stemcells/")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("names stemcells after their IaaS and operating system", func() {
+		Expect(boshio.StemcellName("ubuntu-jammy", "aws")).To(Equal("bosh-aws-xen-hvm-ubuntu-jammy-go_agent"))
+		Expect(boshio.StemcellName("ubuntu-xenial", "warden")).To(Equal("bosh-warden-boshlite-ubuntu-xenial-go_agent"))
+		Expect(boshio.StemcellName("ubuntu-jammy", "aws-xen-hvm")).To(Equal("bosh-aws-xen-hvm-ubuntu-jammy-go_agent"))
+	})
+
+	It("compares versions numerically", func() {
+		Expect(boshio.Compare("1.18", "1.9")).To(Equal(1))
+		Expect(boshio.Compare("621.64", "621.64")).To(Equal(0))
+		Expect(boshio.Compare("1", "1.1")).To(Equal(-1))
+	})
+
+	It("resolves latest to the newest version", func() {
+		s, err := index.Resolve(boshio.Query{OS: "ubuntu-jammy", IaaS: "aws", Version: "latest"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.Version).To(Equal("2.1"))
+		Expect(s.URL).To(Equal("https://example.com/2.1.tgz"))
+		Expect(s.SHA1).To(Equal("aaa21"))
+	})
+
+	It("resolves version lines to the newest version on that line", func() {
+		s, err := index.Resolve(boshio.Query{OS: "ubuntu-jammy", IaaS: "aws", Version: "1.*"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.Name).To(Equal("bosh-aws-xen-hvm-ubuntu-jammy-go_agent"))
+		Expect(s.Version).To(Equal("1.18"))
+	})
+
+	It("resolves exact versions", func() {
+		s, err := index.Resolve(boshio.Query{OS: "ubuntu-jammy", IaaS: "aws", Version: "1.9"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.SHA256).To(Equal("bbb9"))
+	})
+
+	It("resolves light stemcells, where there are any", func() {
+		s, err := index.Resolve(boshio.Query{OS: "ubuntu-jammy", IaaS: "aws", Light: true})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.Version).To(Equal("1.18"))
+		Expect(s.URL).To(Equal("https://example.com/light-1.18.tgz"))
+	})
+
+	It("fails when nothing matches", func() {
+		_, err := index.Resolve(boshio.Query{OS: "ubuntu-jammy", IaaS: "aws", Version: "3.*"})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("fails for stemcells the index doesn't have", func() {
+		_, err := index.Resolve(boshio.Query{OS: "windows2019", IaaS: "aws"})
+		Expect(err).To(HaveOccurred())
+	})
+})
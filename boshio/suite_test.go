@@ -0,0 +1,13 @@
+package boshio_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestBOSHio(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "bosh.io Stemcell Index Suite")
+}
@@ -0,0 +1,55 @@
+/*
+Gluon - BOSH / CF Orchestration via Kuberenetes API(s)
+
+Copyright (c) 2020 James Hunt
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to
+deal in the Software without restriction, including without limitation the
+rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+sell copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software..
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+IN THE SOFTWARE.
+*/
+
+// artifact-cache serves a Gluon artifact cache (see package artifacts)
+// out of a directory, usually a PersistentVolume.  BOSHArtifactCaches
+// run it.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/starkandwayne/gluon-controller/artifacts"
+)
+
+func main() {
+	var dir, addr string
+	var maxSize int64
+	var offline bool
+	flag.StringVar(&dir, "dir", "/cache", "The directory to keep artifacts in.")
+	flag.StringVar(&addr, "addr", ":8080", "The address to serve artifacts on.")
+	flag.Int64Var(&maxSize, "max-size", 0, "How big (in bytes) the cache may get before artifacts are evicted (0 for no limit).")
+	flag.BoolVar(&offline, "offline", false, "Never fetch artifacts from upstream; only serve what the cache has been seeded with.")
+	flag.Parse()
+
+	cache := artifacts.New(dir, maxSize)
+	cache.Offline = offline
+
+	log.Printf("serving artifacts from %s on %s (max size %d bytes, offline: %v)", dir, addr, maxSize, offline)
+	if err := http.ListenAndServe(addr, cache); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,394 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	v1alpha1 "github.com/starkandwayne/gluon-controller/api/v1alpha1"
+)
+
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+var (
+	// AlertsURL is the URL that BOSH health monitors should forward
+	// their alerts to; i.e. where the AlertReceiver can be reached
+	// from the directors.  It has to be https: health monitors send
+	// their token along in the query string.
+	AlertsURL string
+
+	// DegradedFor is how long a BOSHDeployment stays Degraded after
+	// the last alert the health monitor sent about it.
+	DegradedFor = 30 * time.Minute
+)
+
+// an Alert, as sent by the health monitor's consul_event_forwarder plugin.
+type Alert struct {
+	Kind       string `json:"kind"`
+	ID         string `json:"id"`
+	Severity   int    `json:"severity"`
+	Title      string `json:"title"`
+	Summary    string `json:"summary"`
+	Source     string `json:"source"`
+	Deployment string `json:"deployment"`
+	CreatedAt  int64  `json:"created_at"`
+}
+
+// alert sources look like "deployment: job/uuid (index) [id=..., ...]"
+var alertInstance = regexp.MustCompile(`^[^:]+: (\S+)`)
+
+// Instance returns the instance (group/id) that the alert is about,
+// or "" if it isn't about any one instance.
+func (a Alert) Instance() string {
+	if m := alertInstance.FindStringSubmatch(a.Source); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+func (a Alert) String() string {
+	msg := a.Title
+	if a.Summary != "" && a.Summary != a.Title {
+		msg += ": " + a.Summary
+	}
+	if i := a.Instance(); i != "" {
+		msg += " (" + i + ")"
+	}
+	return msg
+}
+
+// EnsureAlertForwarding makes sure that the token Secret and the ops file
+// ConfigMap that point a director's health monitor at the AlertReceiver
+// exist, in namespace ns.  Set cluster if the director is a
+// ClusterBOSHDirector.  Both are owned by the director object.
+func EnsureAlertForwarding(c client.Client, scheme *runtime.Scheme, owner metav1.Object, ns, name string, cluster bool) error {
+	ctx := context.Background()
+
+	if AlertsURL == "" {
+		return fmt.Errorf("cannot forward alerts for director '%s': no --alerts-url given to gluon", owner.GetName())
+	}
+	u, err := url.Parse(AlertsURL)
+	if err != nil {
+		return fmt.Errorf("invalid --alerts-url '%s': %s", AlertsURL, err)
+	}
+	if u.Scheme != "https" {
+		return fmt.Errorf("cannot forward alerts for director '%s': --alerts-url '%s' is not https (health monitors send their token in the URL)", owner.GetName(), AlertsURL)
+	}
+	host, port, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		host, port = u.Host, "443"
+	}
+
+	secret := &corev1.Secret{}
+	err = c.Get(ctx, types.NamespacedName{Namespace: ns, Name: name}, secret)
+	if errors.IsNotFound(err) {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return err
+		}
+		scope := "namespace"
+		if cluster {
+			scope = "cluster"
+		}
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns,
+				Name:      name,
+				Labels: map[string]string{
+					v1alpha1.AlertsForLabel:   owner.GetName(),
+					v1alpha1.AlertsScopeLabel: scope,
+				},
+			},
+			Data: map[string][]byte{
+				"token": []byte(hex.EncodeToString(b)),
+			},
+		}
+		if err := controllerutil.SetControllerReference(owner, secret, scheme); err != nil {
+			return err
+		}
+		if err := c.Create(ctx, secret); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	ops := fmt.Sprintf(`---
+- type: replace
+  path: /instance_groups/name=bosh/properties/hm/consul_event_forwarder_enabled?
+  value: true
+
+- type: replace
+  path: /instance_groups/name=bosh/properties/hm/consul_event_forwarder?
+  value:
+    host:     %s
+    port:     %s
+    protocol: %s
+    params:   token=%s
+    events:   true
+    heartbeats_as_alerts: false
+`, host, port, u.Scheme, string(secret.Data["token"]))
+
+	cm := &corev1.ConfigMap{}
+	err = c.Get(ctx, types.NamespacedName{Namespace: ns, Name: name}, cm)
+	if errors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns,
+				Name:      name,
+			},
+			Data: map[string]string{
+				v1alpha1.AlertsOpsFile: ops,
+			},
+		}
+		if err := controllerutil.SetControllerReference(owner, cm, scheme); err != nil {
+			return err
+		}
+		return c.Create(ctx, cm)
+
+	} else if err != nil {
+		return err
+	}
+
+	if cm.Data[v1alpha1.AlertsOpsFile] != ops {
+		cm.Data = map[string]string{
+			v1alpha1.AlertsOpsFile: ops,
+		}
+		return c.Update(ctx, cm)
+	}
+	return nil
+}
+
+// AlertReceiver listens for alerts forwarded by the health monitors of
+// BOSH directors, and records them as Events (and Degraded conditions)
+// on the BOSHDeployments they are about.  It runs alongside the
+// controllers, in the manager.
+//
+// Given a certificate and key (TLSCert and TLSKey), it serves HTTPS;
+// otherwise, it serves plain HTTP, and something in front of it (an
+// Ingress, say) has to terminate TLS.
+type AlertReceiver struct {
+	client.Client
+	Log      logr.Logger
+	Recorder record.EventRecorder
+	Addr     string
+
+	TLSCert string
+	TLSKey  string
+}
+
+// Start runs the receiver until stop is closed.
+func (r *AlertReceiver) Start(stop <-chan struct{}) error {
+	server := &http.Server{
+		Addr:    r.Addr,
+		Handler: r,
+	}
+
+	go func() {
+		t := time.NewTicker(time.Minute)
+		defer t.Stop()
+		for {
+			select {
+			case <-stop:
+				server.Close()
+				return
+			case <-t.C:
+				r.sweep()
+			}
+		}
+	}()
+
+	var err error
+	if r.TLSCert != "" && r.TLSKey != "" {
+		r.Log.Info("listening for health monitor alerts", "addr", r.Addr, "tls", true)
+		err = server.ListenAndServeTLS(r.TLSCert, r.TLSKey)
+	} else {
+		r.Log.Info("listening for health monitor alerts", "addr", r.Addr, "tls", false)
+		err = server.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func (r *AlertReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// the consul event forwarder PUTs to /v1/event/fire/<label>, and
+	// will also try to register heartbeats as /v1/agent/check/...;
+	// we only care about the former.
+	if req.Method != "PUT" && req.Method != "POST" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !strings.HasPrefix(req.URL.Path, "/v1/event/fire/") {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	director, cluster, ok := r.authenticate(req.URL.Query().Get("token"))
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var alert Alert
+	if err := json.NewDecoder(req.Body).Decode(&alert); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if alert.Kind != "" && alert.Kind != "alert" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if err := r.record(director, cluster, alert); err != nil {
+		r.Log.Info("unable to record health monitor alert", "director", director.Name, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// authenticate finds the director whose alerts token this is.
+func (r *AlertReceiver) authenticate(token string) (types.NamespacedName, bool, bool) {
+	if token == "" {
+		return types.NamespacedName{}, false, false
+	}
+
+	secrets := &corev1.SecretList{}
+	if err := r.List(context.Background(), secrets, client.HasLabels{v1alpha1.AlertsForLabel}); err != nil {
+		r.Log.Info("unable to list alert token secrets", "error", err)
+		return types.NamespacedName{}, false, false
+	}
+	for _, s := range secrets.Items {
+		if subtle.ConstantTimeCompare(s.Data["token"], []byte(token)) == 1 {
+			director := types.NamespacedName{Namespace: s.Namespace, Name: s.Labels[v1alpha1.AlertsForLabel]}
+			if s.Labels[v1alpha1.AlertsScopeLabel] == "cluster" {
+				return types.NamespacedName{Name: director.Name}, true, true
+			}
+			return director, false, true
+		}
+	}
+	return types.NamespacedName{}, false, false
+}
+
+// record attaches the alert to the BOSHDeployment(s) it is about or, if
+// Gluon doesn't know about the deployment, to the director itself.
+func (r *AlertReceiver) record(director types.NamespacedName, cluster bool, alert Alert) error {
+	ctx := context.Background()
+	r.Log.Info("received health monitor alert", "director", director, "deployment", alert.Deployment, "severity", alert.Severity, "title", alert.Title)
+
+	deployments := &v1alpha1.BOSHDeploymentList{}
+	opts := []client.ListOption{}
+	if !cluster {
+		opts = append(opts, client.InNamespace(director.Namespace))
+	}
+	if err := r.List(ctx, deployments, opts...); err != nil {
+		return err
+	}
+
+	found := false
+	for i := range deployments.Items {
+		bd := &deployments.Items[i]
+		if alert.Deployment == "" || bd.Name != alert.Deployment {
+			continue
+		}
+		if (cluster && bd.Spec.ClusterDirector != director.Name) || (!cluster && bd.Spec.Director != director.Name) {
+			continue
+		}
+
+		found = true
+		r.Recorder.Event(bd, corev1.EventTypeWarning, "HealthMonitorAlert", alert.String())
+
+		// severities 1 (alert) through 3 (error) are worth worrying about
+		if alert.Severity > 0 && alert.Severity <= 3 {
+			if err := r.degrade(types.NamespacedName{Namespace: bd.Namespace, Name: bd.Name}, alert); err != nil {
+				return err
+			}
+		}
+	}
+	if found {
+		return nil
+	}
+
+	var obj runtime.Object
+	if cluster {
+		cbd := &v1alpha1.ClusterBOSHDirector{}
+		if err := r.Get(ctx, director, cbd); err != nil {
+			return err
+		}
+		obj = cbd
+	} else {
+		bd := &v1alpha1.BOSHDeployment{}
+		if err := r.Get(ctx, director, bd); err != nil {
+			return err
+		}
+		obj = bd
+	}
+	r.Recorder.Event(obj, corev1.EventTypeWarning, "HealthMonitorAlert", alert.String())
+	return nil
+}
+
+// degrade marks the named BOSHDeployment as Degraded.
+func (r *AlertReceiver) degrade(name types.NamespacedName, alert Alert) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		bd := &v1alpha1.BOSHDeployment{}
+		if err := r.Get(context.Background(), name, bd); err != nil {
+			return err
+		}
+		bd.Status.Conditions.Set(v1alpha1.ConditionDegraded, corev1.ConditionTrue, "HealthMonitorAlert", alert.String())
+		return r.Update(context.Background(), bd)
+	})
+}
+
+// sweep clears the Degraded condition on BOSHDeployments that the health
+// monitor has stopped complaining about.
+func (r *AlertReceiver) sweep() {
+	deployments := &v1alpha1.BOSHDeploymentList{}
+	if err := r.List(context.Background(), deployments); err != nil {
+		r.Log.Info("unable to list deployments", "error", err)
+		return
+	}
+
+	for _, bd := range deployments.Items {
+		c := bd.Status.Conditions.Find(v1alpha1.ConditionDegraded)
+		if c == nil || c.Status != corev1.ConditionTrue || time.Since(c.LastUpdateTime.Time) < DegradedFor {
+			continue
+		}
+
+		name := types.NamespacedName{Namespace: bd.Namespace, Name: bd.Name}
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			bd := &v1alpha1.BOSHDeployment{}
+			if err := r.Get(context.Background(), name, bd); err != nil {
+				return err
+			}
+			bd.Status.Conditions.Set(v1alpha1.ConditionDegraded, corev1.ConditionFalse, "NoRecentAlerts",
+				fmt.Sprintf("no health monitor alerts in the last %s", DegradedFor))
+			return r.Update(context.Background(), bd)
+		})
+		if err != nil {
+			r.Log.Info("unable to clear degraded condition", "boshdeployment", name, "error", err)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1alpha1 "github.com/starkandwayne/gluon-controller/api/v1alpha1"
+)
+
+var _ = Describe("Health Monitor Alerts", func() {
+	const alert = `{
+	  "kind":       "alert",
+	  "id":         "a1b2c3",
+	  "severity":   2,
+	  "title":      "process is not running",
+	  "summary":    "rep has stopped",
+	  "source":     "cf: diego-cell/2a3f (0) [id=2a3f, index=0, cid=vm-1]",
+	  "deployment": "cf",
+	  "created_at": 1580000000
+	}`
+
+	var (
+		recorder *record.FakeRecorder
+		receiver *AlertReceiver
+	)
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(v1alpha1.AddToScheme(scheme)).To(Succeed())
+
+		recorder = record.NewFakeRecorder(10)
+		receiver = &AlertReceiver{
+			Client: fake.NewFakeClientWithScheme(scheme,
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "ns",
+						Name:      "proto-alerts",
+						Labels: map[string]string{
+							v1alpha1.AlertsForLabel:   "proto",
+							v1alpha1.AlertsScopeLabel: "namespace",
+						},
+					},
+					Data: map[string][]byte{"token": []byte("s3cr3t")},
+				},
+				&v1alpha1.BOSHDeployment{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "proto"},
+				},
+				&v1alpha1.BOSHDeployment{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "cf"},
+					Spec:       v1alpha1.BOSHDeploymentSpec{Director: "proto"},
+				}),
+			Log:      logf.NullLogger{},
+			Recorder: recorder,
+		}
+	})
+
+	fire := func(method, path, body string) int {
+		w := httptest.NewRecorder()
+		receiver.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w.Code
+	}
+
+	It("describes alerts by title, summary and instance", func() {
+		a := Alert{
+			Title:   "process is not running",
+			Summary: "rep has stopped",
+			Source:  "cf: diego-cell/2a3f (0) [id=2a3f, index=0, cid=vm-1]",
+		}
+		Expect(a.Instance()).To(Equal("diego-cell/2a3f"))
+		Expect(a.String()).To(Equal("process is not running: rep has stopped (diego-cell/2a3f)"))
+
+		Expect(Alert{Title: "director is unhappy", Source: "director"}.String()).To(Equal("director is unhappy"))
+	})
+
+	It("turns away alerts without the right token", func() {
+		Expect(fire("PUT", "/v1/event/fire/alert", alert)).To(Equal(http.StatusUnauthorized))
+		Expect(fire("PUT", "/v1/event/fire/alert?token=", alert)).To(Equal(http.StatusUnauthorized))
+		Expect(fire("PUT", "/v1/event/fire/alert?token=s3cr3", alert)).To(Equal(http.StatusUnauthorized))
+		Expect(recorder.Events).To(BeEmpty())
+	})
+
+	It("ignores everything but fired events", func() {
+		Expect(fire("GET", "/v1/event/fire/alert?token=s3cr3t", "")).To(Equal(http.StatusMethodNotAllowed))
+		Expect(fire("PUT", "/v1/agent/check/register?token=s3cr3t", "{}")).To(Equal(http.StatusOK))
+		Expect(fire("PUT", "/v1/event/fire/heartbeat?token=s3cr3t", `{"kind":"heartbeat"}`)).To(Equal(http.StatusOK))
+		Expect(recorder.Events).To(BeEmpty())
+	})
+
+	It("rejects alerts it can't parse", func() {
+		Expect(fire("PUT", "/v1/event/fire/alert?token=s3cr3t", "{not json")).To(Equal(http.StatusBadRequest))
+		Expect(recorder.Events).To(BeEmpty())
+	})
+
+	It("records alerts against the deployment they are about", func() {
+		Expect(fire("PUT", "/v1/event/fire/alert?token=s3cr3t", alert)).To(Equal(http.StatusOK))
+		Expect(recorder.Events).To(Receive(Equal("Warning HealthMonitorAlert process is not running: rep has stopped (diego-cell/2a3f)")))
+
+		bd := &v1alpha1.BOSHDeployment{}
+		Expect(receiver.Get(context.Background(), types.NamespacedName{Namespace: "ns", Name: "cf"}, bd)).To(Succeed())
+		Expect(bd.Status.Conditions.IsTrue(v1alpha1.ConditionDegraded)).To(BeTrue())
+	})
+
+	It("records alerts about deployments it doesn't know against the director", func() {
+		Expect(fire("PUT", "/v1/event/fire/alert?token=s3cr3t", strings.Replace(alert, `"deployment": "cf"`, `"deployment": "elsewhere"`, 1))).To(Equal(http.StatusOK))
+		Expect(recorder.Events).To(Receive(HavePrefix("Warning HealthMonitorAlert")))
+
+		bd := &v1alpha1.BOSHDeployment{}
+		Expect(receiver.Get(context.Background(), types.NamespacedName{Namespace: "ns", Name: "cf"}, bd)).To(Succeed())
+		Expect(bd.Status.Conditions.IsTrue(v1alpha1.ConditionDegraded)).To(BeFalse())
+	})
+})
@@ -0,0 +1,188 @@
+/*
+Gluon - BOSH / CF Orchestration via Kuberenetes API(s)
+
+Copyright (c) 2020 James Hunt
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to
+deal in the Software without restriction, including without limitation the
+rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+sell copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software..
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+IN THE SOFTWARE.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	v1alpha1 "github.com/starkandwayne/gluon-controller/api/v1alpha1"
+)
+
+// BOSHArtifactCacheReconciler reconciles a BOSHArtifactCache object
+type BOSHArtifactCacheReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=gluon.starkandwayne.com,resources=boshartifactcaches,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=gluon.starkandwayne.com,resources=boshartifactcaches/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=services;persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
+
+func (r *BOSHArtifactCacheReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("boshartifactcache", req.NamespacedName)
+
+	// fetch the BOSHArtifactCache instance
+	instance := &v1alpha1.BOSHArtifactCache{}
+	err := r.Client.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// that's ok, maybe someone got cold feet and deleted it.
+			return ctrl.Result{}, nil
+		}
+		// something else went wrong...
+		return ctrl.Result{}, err
+	}
+	name := types.NamespacedName{Namespace: instance.Namespace, Name: instance.ResourceName()}
+
+	// the volume claim is left alone once it exists; growing it is
+	// between the operator and the storage class.
+	pvc := &corev1.PersistentVolumeClaim{}
+	err = r.Client.Get(ctx, name, pvc)
+	if errors.IsNotFound(err) {
+		log.Info("creating artifact cache volume claim", "pvc", name.Name, "size", instance.Spec.Size.String())
+		pvc = instance.PersistentVolumeClaim()
+		if err := controllerutil.SetControllerReference(instance, pvc, r.Scheme); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.Client.Create(ctx, pvc); err != nil {
+			return ctrl.Result{}, err
+		}
+	} else if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	svc := &corev1.Service{}
+	err = r.Client.Get(ctx, name, svc)
+	if errors.IsNotFound(err) {
+		log.Info("creating artifact cache service", "service", name.Name)
+		svc = instance.Service()
+		if err := controllerutil.SetControllerReference(instance, svc, r.Scheme); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.Client.Create(ctx, svc); err != nil {
+			return ctrl.Result{}, err
+		}
+	} else if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	want := instance.Deployment()
+	deployment := &appsv1.Deployment{}
+	err = r.Client.Get(ctx, name, deployment)
+	if errors.IsNotFound(err) {
+		log.Info("creating artifact cache deployment", "deployment", name.Name)
+		deployment = want
+		if err := controllerutil.SetControllerReference(instance, deployment, r.Scheme); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.Client.Create(ctx, deployment); err != nil {
+			return ctrl.Result{}, err
+		}
+	} else if err != nil {
+		return ctrl.Result{}, err
+	} else {
+		// max size and offline-ness can change on the fly
+		have := &deployment.Spec.Template.Spec.Containers[0]
+		if !reflect.DeepEqual(have.Args, want.Spec.Template.Spec.Containers[0].Args) || have.Image != v1alpha1.CacheImage {
+			log.Info("updating artifact cache deployment", "deployment", name.Name)
+			have.Args = want.Spec.Template.Spec.Containers[0].Args
+			have.Image = v1alpha1.CacheImage
+			if err := r.Client.Update(ctx, deployment); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	instance.Status.URL = instance.URL()
+	instance.Status.Ready = deployment.Status.ReadyReplicas > 0
+	if instance.Status.Ready {
+		instance.Status.State = v1alpha1.StateResolved
+	} else {
+		instance.Status.State = v1alpha1.StatePending
+	}
+	return ctrl.Result{}, r.Update(ctx, instance)
+}
+
+// ArtifactCacheURL returns where the named BOSHArtifactCache can be
+// reached, or the empty string if it isn't ready (or doesn't exist).
+func ArtifactCacheURL(c client.Client, ns, name string) (string, error) {
+	cache := &v1alpha1.BOSHArtifactCache{}
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: ns, Name: name}, cache); err != nil {
+		if errors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	if !cache.Status.Ready {
+		return "", nil
+	}
+	return cache.Status.URL, nil
+}
+
+// ArtifactCached asks an artifact cache whether it (still) has the
+// artifact at url, without fetching it from anywhere if it doesn't.
+func ArtifactCached(url string) (bool, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	res, err := client.Head(url)
+	if err != nil {
+		return false, err
+	}
+	res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, fmt.Errorf("checking %s: %s", url, res.Status)
+}
+
+func (r *BOSHArtifactCacheReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.BOSHArtifactCache{}).
+		Owns(&appsv1.Deployment{}).
+		Owns(&corev1.Service{}).
+		Owns(&corev1.PersistentVolumeClaim{}).
+		Complete(r)
+}
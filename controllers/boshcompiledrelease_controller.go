@@ -0,0 +1,442 @@
+/*
+Gluon - BOSH / CF Orchestration via Kuberenetes API(s)
+
+Copyright (c) 2020 James Hunt
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to
+deal in the Software without restriction, including without limitation the
+rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+sell copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software..
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+IN THE SOFTWARE.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	v1alpha1 "github.com/starkandwayne/gluon-controller/api/v1alpha1"
+)
+
+// BOSHCompiledReleaseReconciler reconciles a BOSHCompiledRelease object
+type BOSHCompiledReleaseReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=gluon.starkandwayne.com,resources=boshcompiledreleases,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=gluon.starkandwayne.com,resources=boshcompiledreleases/status,verbs=get;update;patch
+
+func (r *BOSHCompiledReleaseReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("boshcompiledrelease", req.NamespacedName)
+
+	// fetch the BOSHCompiledRelease instance
+	instance := &v1alpha1.BOSHCompiledRelease{}
+	err := r.Client.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// that's ok, maybe someone got cold feet and deleted it.
+			return ctrl.Result{}, nil
+		}
+		// something else went wrong...
+		return ctrl.Result{}, err
+	}
+
+	// check to see if our dependencies are resolved
+	log.Info("checking dependencies")
+	if ok, info, err := instance.Dependencies.Resolved(r.Client, instance.Namespace); !ok {
+		if err != nil {
+			log.Info("failed to determine if dependencies are resolved", "dependency", info, "error", err)
+		} else {
+			log.Info("dependencies not yet resolved", "dependency", info)
+		}
+		return instance.Dependencies.Requeue(), err
+	}
+
+	// compiled releases are kept in the artifact cache
+	url, err := ArtifactCacheURL(r.Client, instance.Namespace, instance.Spec.Cache)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if url == "" {
+		log.Info("artifact cache not ready (yet)", "cache", instance.Spec.Cache)
+		instance.Status.Ready, instance.Status.State = false, v1alpha1.StatePending
+		if err := r.Update(ctx, instance); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: QueueRetryAfter}, nil
+	}
+	instance.Status.CacheURL = url
+
+	source, err := LookupDirector(r.Client, r.Scheme, instance.Namespace, instance.Spec.Director, instance.Spec.ClusterDirector)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if source == nil {
+		log.Info("compiling director not found (yet)")
+		instance.Status.Ready, instance.Status.State = false, v1alpha1.StatePending
+		return ctrl.Result{}, r.Update(ctx, instance)
+	}
+
+	// a change of release or stemcell means a new export
+	if e := instance.Status.Exported; e != nil && !e.Matches(instance.Spec.Release, instance.Spec.Stemcell) {
+		log.Info("release or stemcell changed; exporting again", "release", instance.Spec.Release.String(), "stemcell", instance.Spec.Stemcell.String())
+		if err := r.forget(instance, source); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// first, export the compiled release
+	instance.Status.CurrentTask = nil
+	result := ctrl.Result{}
+	if instance.Status.Exported == nil {
+		requeue, err := r.export(instance, source)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		result.RequeueAfter = requeue
+		instance.Status.CurrentTask = instance.Status.Export.CurrentTask
+		instance.Status.Ready, instance.Status.State = false, instance.Status.Export.State
+		if err := r.Update(ctx, instance); err != nil {
+			return ctrl.Result{}, err
+		}
+		return result, nil
+	}
+
+	// then, upload it everywhere else
+	directors, err := TargetDirectors(r.Client, r.Scheme, instance.Namespace, "", "", instance.Spec.UploadTo)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// the artifact cache evicts whatever hasn't been used in a while;
+	// if the export is gone by the time a director needs it, export
+	// the release again rather than fail the upload.
+	if r.uploading(instance, directors) {
+		if digests, err := v1alpha1.ParseDigests(instance.Status.Exported.Digest); err == nil {
+			instance.Status.Exported.URL = v1alpha1.ExportedURL(instance.Status.CacheURL, digests)
+		}
+		if cached, err := ArtifactCached(instance.Status.Exported.URL); err != nil {
+			log.Info("unable to check the artifact cache for the compiled release", "url", instance.Status.Exported.URL, "error", err)
+		} else if !cached {
+			log.Info("compiled release is no longer in the artifact cache; exporting it again", "url", instance.Status.Exported.URL)
+			if err := r.reexport(instance); err != nil {
+				return ctrl.Result{}, err
+			}
+			instance.Status.Ready, instance.Status.State = false, v1alpha1.StatePending
+			if err := r.Update(ctx, instance); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{RequeueAfter: QueueRetryAfter}, nil
+		}
+	}
+	statuses := []v1alpha1.DirectorStatus{instance.Status.Export}
+	targeted := []v1alpha1.ReleaseDirectorStatus{}
+	for _, director := range directors {
+		if instance.Status.Export.Is(director) {
+			// compiled there in the first place
+			continue
+		}
+		status := instance.DirectorStatus(director)
+		requeue, err := r.upload(instance, director, status)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if requeue > 0 && (result.RequeueAfter == 0 || requeue < result.RequeueAfter) {
+			result.RequeueAfter = requeue
+		}
+		if status.CurrentTask != nil && instance.Status.CurrentTask == nil {
+			instance.Status.CurrentTask = status.CurrentTask
+		}
+		targeted = append(targeted, *status)
+		statuses = append(statuses, status.DirectorStatus)
+	}
+	instance.Status.Directors = targeted
+
+	instance.Status.Ready, instance.Status.State = v1alpha1.Aggregate(statuses)
+	if err := r.Update(ctx, instance); err != nil {
+		return ctrl.Result{}, err
+	}
+	return result, nil
+}
+
+// export runs `bosh export-release` on the compiling director, and works
+// out where the result ended up in the artifact cache.
+func (r *BOSHCompiledReleaseReconciler) export(instance *v1alpha1.BOSHCompiledRelease, director v1alpha1.Director) (time.Duration, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("boshcompiledrelease", types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}, "director", director.GetName())
+
+	status := &instance.Status.Export
+	if !status.Is(director) {
+		*status = v1alpha1.DirectorStatus{Director: director.GetName(), State: v1alpha1.StatePending}
+		if _, ok := director.(*v1alpha1.ClusterBOSHDirector); ok {
+			*status = v1alpha1.DirectorStatus{ClusterDirector: director.GetName(), State: v1alpha1.StatePending}
+		}
+	}
+
+	job := &batchv1.Job{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: instance.ExportJobName()}, job)
+	if err == nil {
+		if !job.ObjectMeta.DeletionTimestamp.IsZero() {
+			// left over from a previous export; wait for it to go away
+			status.Ready, status.State = false, v1alpha1.StatePending
+			return QueueRetryAfter, nil
+		}
+		status.Ready, status.State = v1alpha1.DetermineReadiness(job)
+		if task, err := TrackTask(r.Client, instance.Namespace, director, job); err != nil {
+			log.Info("unable to track director task", "error", err)
+		} else {
+			status.CurrentTask = task
+		}
+		if !Finished(job) {
+			// compiling can take a while
+			return TaskPollInterval, nil
+		}
+		if status.State != v1alpha1.StateResolved {
+			return 0, nil
+		}
+
+		digests, err := v1alpha1.ParseDigests(job.Annotations[v1alpha1.DigestAnnotation])
+		if err != nil {
+			log.Info("export job did not record the digest of the compiled release", "job", job.Name, "error", err)
+			status.Ready, status.State = false, v1alpha1.StateFailed
+			return 0, nil
+		}
+		instance.Status.Exported = &v1alpha1.ExportedRelease{
+			Release:  instance.Spec.Release,
+			Stemcell: instance.Spec.Stemcell,
+			URL:      v1alpha1.ExportedURL(instance.Status.CacheURL, digests),
+			Digest:   digests.String(),
+		}
+		log.Info("exported compiled release", "release", instance.Spec.Release.String(), "stemcell", instance.Spec.Stemcell.String(), "url", instance.Status.Exported.URL)
+		return 0, nil
+
+	} else if !errors.IsNotFound(err) {
+		return 0, err
+	}
+
+	// wait our turn if the director is already busy
+	if queued, err := Queued(r.Client, director, v1alpha1.OperationRelease); err != nil {
+		return 0, err
+	} else if queued {
+		log.Info("director is busy; queueing release export")
+		status.Ready, status.State = false, v1alpha1.StateQueued
+		return QueueRetryAfter, nil
+	}
+
+	log.Info("creating release export job", "job", instance.ExportJobName())
+	status.Ready, status.State = v1alpha1.DetermineReadiness(nil)
+	job = instance.ExportJob(director)
+	if err := controllerutil.SetControllerReference(instance, job, r.Scheme); err != nil {
+		return 0, err
+	}
+	if admitted, err := CreateQueued(r.Client, director, v1alpha1.OperationRelease, job); err != nil {
+		return 0, err
+	} else if !admitted {
+		log.Info("director got busy; queueing release export")
+		status.Ready, status.State = false, v1alpha1.StateQueued
+		return QueueRetryAfter, nil
+	}
+	return 0, nil
+}
+
+// upload sends the exported release to one director, once that director
+// has a stemcell to go with it.
+func (r *BOSHCompiledReleaseReconciler) upload(instance *v1alpha1.BOSHCompiledRelease, director v1alpha1.Director, status *v1alpha1.ReleaseDirectorStatus) (time.Duration, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("boshcompiledrelease", types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}, "director", director.GetName())
+
+	job := &batchv1.Job{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: instance.UploadJobName(director)}, job)
+	if err == nil {
+		if !job.ObjectMeta.DeletionTimestamp.IsZero() {
+			// left over from a previous export; wait for it to go away
+			status.Ready, status.State = false, v1alpha1.StatePending
+			return QueueRetryAfter, nil
+		}
+		status.Ready, status.State = v1alpha1.DetermineReadiness(job)
+		if task, err := TrackTask(r.Client, instance.Namespace, director, job); err != nil {
+			log.Info("unable to track director task", "error", err)
+		} else {
+			status.CurrentTask = task
+		}
+		if !Finished(job) {
+			return TaskPollInterval, nil
+		}
+		if status.State == v1alpha1.StateResolved && status.Uploaded == nil {
+			status.Uploaded = &v1alpha1.UploadedRelease{
+				Name:    instance.Status.Exported.Release.Name,
+				Version: instance.Status.Exported.Release.Version,
+				Digest:  job.Annotations[v1alpha1.DigestAnnotation],
+			}
+		}
+		return 0, nil
+
+	} else if !errors.IsNotFound(err) {
+		return 0, err
+	}
+
+	// compiled packages are only any use alongside the stemcell they
+	// were compiled against
+	if ok, err := r.hasStemcell(instance, director, instance.Status.Exported.Stemcell); err != nil {
+		return 0, err
+	} else if !ok {
+		log.Info("waiting for stemcell to be uploaded to director", "stemcell", instance.Status.Exported.Stemcell.String())
+		status.Ready, status.State = false, v1alpha1.StatePending
+		return QueueRetryAfter, nil
+	}
+
+	if queued, err := Queued(r.Client, director, v1alpha1.OperationRelease); err != nil {
+		return 0, err
+	} else if queued {
+		log.Info("director is busy; queueing compiled release upload")
+		status.Ready, status.State = false, v1alpha1.StateQueued
+		return QueueRetryAfter, nil
+	}
+
+	log.Info("creating compiled release upload job", "job", instance.UploadJobName(director))
+	status.Ready, status.State = v1alpha1.DetermineReadiness(nil)
+	job = instance.UploadJob(director)
+	if err := controllerutil.SetControllerReference(instance, job, r.Scheme); err != nil {
+		return 0, err
+	}
+	if admitted, err := CreateQueued(r.Client, director, v1alpha1.OperationRelease, job); err != nil {
+		return 0, err
+	} else if !admitted {
+		log.Info("director got busy; queueing compiled release upload")
+		status.Ready, status.State = false, v1alpha1.StateQueued
+		return QueueRetryAfter, nil
+	}
+	return 0, nil
+}
+
+// hasStemcell asks the director whether it has a stemcell with the given
+// operating system and version.
+func (r *BOSHCompiledReleaseReconciler) hasStemcell(instance *v1alpha1.BOSHCompiledRelease, director v1alpha1.Director, stemcell v1alpha1.StemcellRef) (bool, error) {
+	d, err := DirectorClient(r.Client, instance.Namespace, director.SecretsName())
+	if err != nil {
+		return false, err
+	}
+	stemcells, err := d.Stemcells()
+	if err != nil {
+		return false, fmt.Errorf("unable to list stemcells on director %s: %s", director.GetName(), err)
+	}
+	for _, s := range stemcells {
+		if s.OperatingSystem == stemcell.OS && s.Version == stemcell.Version {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// uploading returns true if any of the directors (other than the one it
+// was compiled on) has yet to get the compiled release.
+func (r *BOSHCompiledReleaseReconciler) uploading(instance *v1alpha1.BOSHCompiledRelease, directors []v1alpha1.Director) bool {
+	for _, director := range directors {
+		if instance.Status.Export.Is(director) {
+			continue
+		}
+		if status := instance.DirectorStatus(director); status.Uploaded == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// reexport throws away the export Job (and any upload Jobs that have
+// yet to succeed, since they would only fail for want of the tarball),
+// so that the release gets exported into the cache anew.  Directors
+// that already have the compiled release are left be.
+func (r *BOSHCompiledReleaseReconciler) reexport(instance *v1alpha1.BOSHCompiledRelease) error {
+	ctx := context.Background()
+	background := metav1.DeletePropagationBackground
+
+	names := []string{instance.ExportJobName()}
+	for _, s := range instance.Status.Directors {
+		if s.Uploaded != nil {
+			continue
+		}
+		director, err := LookupDirector(r.Client, r.Scheme, instance.Namespace, s.Director, s.ClusterDirector)
+		if err != nil {
+			return err
+		}
+		if director != nil {
+			names = append(names, instance.UploadJobName(director))
+		}
+	}
+	for _, name := range names {
+		job := &batchv1.Job{}
+		job.Namespace, job.Name = instance.Namespace, name
+		if err := r.Client.Delete(ctx, job, &client.DeleteOptions{PropagationPolicy: &background}); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	instance.Status.Exported = nil
+	instance.Status.Export = v1alpha1.DirectorStatus{}
+	return nil
+}
+
+// forget clears out a previous export (and the uploads of it), so that
+// the release can be exported afresh.
+func (r *BOSHCompiledReleaseReconciler) forget(instance *v1alpha1.BOSHCompiledRelease, source v1alpha1.Director) error {
+	ctx := context.Background()
+	background := metav1.DeletePropagationBackground
+
+	names := []string{instance.ExportJobName()}
+	for _, s := range instance.Status.Directors {
+		director, err := LookupDirector(r.Client, r.Scheme, instance.Namespace, s.Director, s.ClusterDirector)
+		if err != nil {
+			return err
+		}
+		if director != nil {
+			names = append(names, instance.UploadJobName(director))
+		}
+	}
+	for _, name := range names {
+		job := &batchv1.Job{}
+		job.Namespace, job.Name = instance.Namespace, name
+		if err := r.Client.Delete(ctx, job, &client.DeleteOptions{PropagationPolicy: &background}); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	instance.Status.Exported = nil
+	instance.Status.Directors = nil
+	instance.Status.Export = v1alpha1.DirectorStatus{}
+	return nil
+}
+
+func (r *BOSHCompiledReleaseReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.BOSHCompiledRelease{}).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
@@ -26,6 +26,7 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -42,6 +43,8 @@ import (
 	v1alpha1 "github.com/starkandwayne/gluon-controller/api/v1alpha1"
 )
 
+const ConfigFinalizer = "boshconfig.gluon.starkandwayne.com"
+
 // BOSHConfigReconciler reconciles a BOSHConfig object
 type BOSHConfigReconciler struct {
 	client.Client
@@ -68,6 +71,24 @@ func (r *BOSHConfigReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error)
 		return ctrl.Result{}, err
 	}
 
+	// register our finalizer, or act on it if we are being deleted
+	if instance.ObjectMeta.DeletionTimestamp.IsZero() {
+		if !HasFinalizer(instance, ConfigFinalizer) {
+			if PredatesFinalizer(instance.Status.State, &instance.Spec.DeletionPolicy) {
+				log.Info("config predates deletion policies; leaving it in place when deleted", "policy", instance.Spec.DeletionPolicy)
+			}
+			controllerutil.AddFinalizer(instance, ConfigFinalizer)
+			if err := r.Update(ctx, instance); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	} else {
+		if HasFinalizer(instance, ConfigFinalizer) {
+			return r.finalize(instance)
+		}
+		return ctrl.Result{}, nil
+	}
+
 	// check to see if our dependencies are resolved
 	log.Info("checking dependencies")
 	if ok, info, err := instance.Dependencies.Resolved(r.Client, req.Namespace); !ok {
@@ -79,11 +100,48 @@ func (r *BOSHConfigReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error)
 		return instance.Dependencies.Requeue(), err
 	}
 
+	// retrieve our upstream director
+	director, err := LookupDirector(r.Client, r.Scheme, req.Namespace, instance.Spec.Director, instance.Spec.ClusterDirector)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if director == nil {
+		// director was there once, but is gone now
+		return ctrl.Result{}, nil
+	}
+
+	// each revision of the config gets its own Job; an update that is
+	// still running for the previous revision is followed to the end
+	// before the ConfigMap is swapped out from under it.
+	revision := instance.Revision()
+	name, following := instance.JobName(director), false
+	if previous := instance.Status.Revision; previous != "" && previous != revision {
+		superseded := &batchv1.Job{}
+		err := r.Client.Get(ctx, types.NamespacedName{Namespace: req.Namespace, Name: instance.RevisionJobName(director, previous)}, superseded)
+		if err == nil && !Finished(superseded) {
+			log.Info("waiting for update to previous revision to finish", "job", superseded.Name, "revision", previous)
+			name, following = superseded.Name, true
+
+		} else if err == nil {
+			log.Info("cleaning up update job for previous revision", "job", superseded.Name, "revision", previous)
+			background := metav1.DeletePropagationBackground
+			if err := r.Client.Delete(ctx, superseded, &client.DeleteOptions{PropagationPolicy: &background}); err != nil && !errors.IsNotFound(err) {
+				return ctrl.Result{}, err
+			}
+
+		} else if !errors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+	}
+
 	// create the ConfigMap for this BOSHConfig
 	log.Info("checking for backing config map", "configmap", instance.Name)
 	config := &corev1.ConfigMap{}
 	err = r.Client.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}, config)
-	if err == nil {
+	if err == nil && following {
+		log.Info("leaving backing config map alone until then", "configmap", instance.Name)
+
+	} else if err == nil {
 		log.Info("updating backing config map", "configmap", instance.Name)
 		config.Data["config.yml"] = instance.Spec.Config
 
@@ -117,31 +175,91 @@ func (r *BOSHConfigReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error)
 		return ctrl.Result{}, err
 	}
 
-	// retrieve our upstream director
-	director := &v1alpha1.BOSHDeployment{}
-	err = r.Client.Get(ctx, types.NamespacedName{Namespace: req.Namespace, Name: instance.Spec.Director}, director)
-	if err != nil {
-		if errors.IsNotFound(err) {
-			// director was there once, but is gone now
+	job := &batchv1.Job{}
+	err = r.Client.Get(ctx, types.NamespacedName{Namespace: req.Namespace, Name: name}, job)
+	if err == nil {
+		if instance.Spec.Cancel && (!Finished(job) || instance.Status.State == v1alpha1.StateCancelling) {
+			log.Info("cancelling job", "job", job.Name)
+			gone, err := CancelJob(r.Client, req.Namespace, director, job)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if task, err := TrackTask(r.Client, req.Namespace, director, job); err == nil && task != nil {
+				instance.Status.CurrentTask = task
+			}
+			instance.Status.Ready, instance.Status.State = false, v1alpha1.StateCancelling
+			if gone {
+				instance.Status.State = v1alpha1.StateCancelled
+			}
+			if err := r.Update(ctx, instance); err != nil {
+				return ctrl.Result{}, err
+			}
+			if !gone {
+				return ctrl.Result{RequeueAfter: TaskPollInterval}, nil
+			}
 			return ctrl.Result{}, nil
 		}
-		return ctrl.Result{}, err
-	}
 
-	job := &batchv1.Job{}
-	err = r.Client.Get(ctx, types.NamespacedName{Namespace: req.Namespace, Name: instance.JobName(director)}, job)
-	if err == nil {
 		// job exists; we may have gotten a reconcile request based on our watch(es)
 		instance.Status.Ready, instance.Status.State = v1alpha1.DetermineReadiness(job)
+		if task, err := TrackTask(r.Client, req.Namespace, director, job); err != nil {
+			log.Info("unable to track director task", "error", err)
+		} else {
+			instance.Status.CurrentTask = task
+		}
+
+		result := ctrl.Result{}
+		if following {
+			// superseded; not ready until the current revision is applied
+			instance.Status.Ready = false
+			result.Requeue = Finished(job)
+
+		} else if instance.Status.State == v1alpha1.StateResolved && instance.Status.ConfigID == "" {
+			// remember what the director calls this revision
+			if id, err := r.configID(instance, director); err != nil {
+				log.Info("unable to look up config id on director", "error", err)
+				result.RequeueAfter = QueueRetryAfter
+			} else {
+				log.Info("config updated on director", "revision", revision, "id", id)
+				now := metav1.Now()
+				instance.Status.ConfigID, instance.Status.UpdatedAt = id, &now
+			}
+		}
 		if err := r.Update(ctx, instance); err != nil {
 			return ctrl.Result{}, err
 		}
+		if !Finished(job) {
+			// keep an eye on the task while it runs
+			return ctrl.Result{RequeueAfter: TaskPollInterval}, nil
+		}
+		return result, nil
 
 	} else if !errors.IsNotFound(err) {
 		return ctrl.Result{}, err
 
 	} else {
+		// don't start anything new until un-cancelled
+		if instance.Spec.Cancel {
+			log.Info("cancelled; not starting a new config update")
+			instance.Status.Ready, instance.Status.State = false, v1alpha1.StateCancelled
+			return ctrl.Result{}, r.Update(ctx, instance)
+		}
+
+		// wait our turn if the director is already busy
+		if queued, err := Queued(r.Client, director, v1alpha1.OperationConfig); err != nil {
+			return ctrl.Result{}, err
+		} else if queued {
+			log.Info("director is busy; queueing config update", "director", director.GetName())
+			instance.Status.Ready, instance.Status.State = false, v1alpha1.StateQueued
+			if err := r.Update(ctx, instance); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{RequeueAfter: QueueRetryAfter}, nil
+		}
+
+		log.Info("creating config update job", "job", instance.JobName(director), "revision", revision)
 		instance.Status.Ready, instance.Status.State = v1alpha1.DetermineReadiness(nil)
+		instance.Status.Revision, instance.Status.ConfigID = revision, ""
 		if err := r.Update(ctx, instance); err != nil {
 			return ctrl.Result{}, err
 		}
@@ -151,14 +269,114 @@ func (r *BOSHConfigReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error)
 		if err := controllerutil.SetControllerReference(instance, job, r.Scheme); err != nil {
 			return ctrl.Result{}, err
 		}
-		if err = r.Client.Create(ctx, job); err != nil {
+		if admitted, err := CreateQueued(r.Client, director, v1alpha1.OperationConfig, job); err != nil {
 			return ctrl.Result{}, err
+		} else if !admitted {
+			log.Info("director got busy; queueing config update", "director", director.GetName())
+			instance.Status.Ready, instance.Status.State = false, v1alpha1.StateQueued
+			if err := r.Update(ctx, instance); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{RequeueAfter: QueueRetryAfter}, nil
 		}
 	}
 
 	return ctrl.Result{}, nil
 }
 
+// configID asks the director for the ID of the (latest) config that
+// this BOSHConfig manages.
+func (r *BOSHConfigReconciler) configID(instance *v1alpha1.BOSHConfig, director v1alpha1.Director) (string, error) {
+	d, err := DirectorClient(r.Client, instance.Namespace, director.SecretsName())
+	if err != nil {
+		return "", err
+	}
+	configs, err := d.Configs(instance.Spec.Type, instance.ConfigName())
+	if err != nil {
+		return "", err
+	}
+	if len(configs) == 0 {
+		return "", fmt.Errorf("no %s config named '%s' on director %s", instance.Spec.Type, instance.ConfigName(), director.GetName())
+	}
+	return configs[0].ID, nil
+}
+
+// finalize removes the config from the director if its deletion policy
+// says to, and lets go of the BOSHConfig once that is done.
+func (r *BOSHConfigReconciler) finalize(instance *v1alpha1.BOSHConfig) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("boshconfig", types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name})
+
+	if !instance.Spec.DeletionPolicy.Deletes() {
+		log.Info("leaving config in place", "policy", instance.Spec.DeletionPolicy)
+		return ctrl.Result{}, r.release(instance)
+	}
+
+	director, err := LookupDirector(r.Client, r.Scheme, instance.Namespace, instance.Spec.Director, instance.Spec.ClusterDirector)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if director == nil {
+		log.Info("director not found; leaving config in place", "director", instance.Spec.Director, "cluster-director", instance.Spec.ClusterDirector)
+		return ctrl.Result{}, r.release(instance)
+	}
+
+	job := &batchv1.Job{}
+	err = r.Client.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: instance.DeleteJobName(director)}, job)
+	if err == nil {
+		instance.Status.Ready, instance.Status.State = v1alpha1.DetermineReadiness(job)
+		if err := r.Update(ctx, instance); err != nil {
+			return ctrl.Result{}, err
+		}
+		if !Finished(job) {
+			return ctrl.Result{}, nil
+		}
+		if instance.Status.State == v1alpha1.StateFailed {
+			log.Info("delete-config failed; not letting go of config", "job", job.Name)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, r.release(instance)
+
+	} else if !errors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+
+	if queued, err := Queued(r.Client, director, v1alpha1.OperationConfig); err != nil {
+		return ctrl.Result{}, err
+	} else if queued {
+		log.Info("director is busy; queueing config removal", "director", director.GetName())
+		instance.Status.Ready, instance.Status.State = false, v1alpha1.StateQueued
+		if err := r.Update(ctx, instance); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: QueueRetryAfter}, nil
+	}
+
+	log.Info("creating delete-config job", "job", instance.DeleteJobName(director))
+	job = instance.DeleteJob(director)
+	if err := controllerutil.SetControllerReference(instance, job, r.Scheme); err != nil {
+		return ctrl.Result{}, err
+	}
+	if admitted, err := CreateQueued(r.Client, director, v1alpha1.OperationConfig, job); err != nil {
+		return ctrl.Result{}, err
+	} else if !admitted {
+		log.Info("director got busy; queueing config removal", "director", director.GetName())
+		instance.Status.Ready, instance.Status.State = false, v1alpha1.StateQueued
+		if err := r.Update(ctx, instance); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: QueueRetryAfter}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// release removes our finalizer, so that Kubernetes can finish deleting
+// the BOSHConfig (and garbage-collect whatever it still owns).
+func (r *BOSHConfigReconciler) release(instance *v1alpha1.BOSHConfig) error {
+	controllerutil.RemoveFinalizer(instance, ConfigFinalizer)
+	return r.Update(context.Background(), instance)
+}
+
 func (r *BOSHConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.BOSHConfig{}).
@@ -25,8 +25,11 @@ IN THE SOFTWARE.
 package controllers
 
 import (
+	"bytes"
 	"context"
+	"encoding/pem"
 	"fmt"
+	"strings"
 
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -34,6 +37,7 @@ import (
 
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -71,61 +75,23 @@ func (r *BOSHDeploymentReconciler) Reconcile(req ctrl.Request) (ctrl.Result, err
 		return ctrl.Result{}, err
 	}
 
-	/*
-		// register finalizer if desired
-		if instance.ObjectMeta.DeletionTimestamp.IsZero() {
-			// we are not being deleted, so if we do not have our finalizer,
-			// then add it and update.
-			if !HasFinalizer(instance, Finalizer) {
-				instance.ObjectMeta.Finalizers = append(instance.ObjectMeta.Finalizers, Finalizer)
-				if err := r.Update(context.Background(), instance); err != nil {
-					return ctrl.Result{}, err
-				}
+	// register our finalizer, or act on it if we are being deleted
+	if instance.ObjectMeta.DeletionTimestamp.IsZero() {
+		if !HasFinalizer(instance, Finalizer) {
+			if PredatesFinalizer(instance.Status.State, &instance.Spec.DeletionPolicy) {
+				log.Info("deployment predates deletion policies; leaving it in place when deleted", "policy", instance.Spec.DeletionPolicy)
 			}
-		} else {
-			// we are being deleted; is our finalizer still listed?
-			if HasFinalizer(instance, Finalizer) {
-				// create the Job resource, in all of its glory
-				found := &batchv1.Job{}
-				err = r.Client.Get(ctx, types.NamespacedName{Namespace: req.Namespace, Name: instance.JobName("teardown")}, found)
-				if err != nil && errors.IsNotFound(err) {
-					job, err := instance.TeardownJob()
-					if err != nil {
-						return ctrl.Result{}, err
-					}
-
-					// rather than set an ownership record, just set a TTL
-					var week int32 = 86400 * 7
-					job.Spec.TTLSecondsAfterFinished = &week
-
-					err = r.Client.Create(ctx, job)
-					if err != nil {
-						return ctrl.Result{}, err
-					}
-
-					// move the pvc ownership over to the teardown job
-					pvc := &corev1.PersistentVolumeClaim{}
-					err = r.Client.Get(ctx, types.NamespacedName{Namespace: req.Namespace, Name: instance.StateVolumeName()}, pvc)
-					if err == nil {
-						if err := controllerutil.SetControllerReference(job, pvc, r.Scheme); err != nil {
-							return ctrl.Result{}, err
-						}
-					}
-
-				} else if err != nil {
-					return ctrl.Result{}, err
-				}
-			}
-
-			// remove our finalizer from the list and update it.
-			instance.ObjectMeta.Finalizers = controllerutil.RemoveFinalizer(instance, Finalizer)
-			if err := r.Update(context.Background(), instance); err != nil {
+			controllerutil.AddFinalizer(instance, Finalizer)
+			if err := r.Update(ctx, instance); err != nil {
 				return ctrl.Result{}, err
 			}
-
-			return ctrl.Result{}, nil
 		}
-	*/
+	} else {
+		if HasFinalizer(instance, Finalizer) {
+			return r.finalize(instance)
+		}
+		return ctrl.Result{}, nil
+	}
 
 	// check to see if our dependencies are resolved
 	log.Info("checking dependencies")
@@ -138,12 +104,54 @@ func (r *BOSHDeploymentReconciler) Reconcile(req ctrl.Request) (ctrl.Result, err
 		return instance.Dependencies.Requeue(), err
 	}
 
+	// find the director we are deploying via, if any
+	// (this also copies in the credentials for cluster directors)
+	var director v1alpha1.Director
+	if instance.ViaDirector() {
+		director, err = LookupDirector(r.Client, r.Scheme, req.Namespace, instance.Spec.Director, instance.Spec.ClusterDirector)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if director == nil {
+			log.Info("director not found", "director", instance.Spec.Director, "cluster-director", instance.Spec.ClusterDirector)
+			return ctrl.Result{}, nil
+		}
+	}
+
+	// directors that forward their health monitor alerts to us
+	// need the ops file (and token) for that before they deploy.
+	if !instance.ViaDirector() && instance.Spec.ForwardAlerts {
+		log.Info("checking alert forwarding configuration", "configmap", instance.AlertsName())
+		if err := EnsureAlertForwarding(r.Client, r.Scheme, instance, req.Namespace, instance.AlertsName(), false); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// create-env directors encrypt their vars-store with a key of
+	// their own
+	if !instance.ViaDirector() {
+		if err := EnsureStateKey(r.Client, instance); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
 	// first we make a volume for our state files / creds / vars
-	if instance.Spec.Director == "" {
+	if instance.UsesStateVolume() {
 		log.Info("checking for persistent state volume", "pvc", instance.StateVolumeName())
 		stateVolume := &corev1.PersistentVolumeClaim{}
 		err = r.Client.Get(ctx, types.NamespacedName{Namespace: req.Namespace, Name: instance.StateVolumeName()}, stateVolume)
 		if err != nil && errors.IsNotFound(err) {
+			// if we have deployed this director before, the state volume
+			// has been lost; the create-env / delete-env jobs will restore
+			// the saved state files into the new volume before they run.
+			saved := &corev1.ConfigMap{}
+			err = r.Client.Get(ctx, types.NamespacedName{Namespace: req.Namespace, Name: instance.StateConfigMapName()}, saved)
+			if err == nil {
+				log.Info("persistent state volume is missing; will restore from saved state", "pvc", instance.StateVolumeName(), "configmap", instance.StateConfigMapName(), "secret", instance.StateSecretName())
+			} else if !errors.IsNotFound(err) {
+				return ctrl.Result{}, err
+			}
+
 			log.Info("creating persistent volume claim", "pvc", instance.StateVolumeName())
 			stateVolume = instance.StateVolume()
 			if err := controllerutil.SetControllerReference(instance, stateVolume, r.Scheme); err != nil {
@@ -166,16 +174,157 @@ func (r *BOSHDeploymentReconciler) Reconcile(req ctrl.Request) (ctrl.Result, err
 	job := &batchv1.Job{}
 	err = r.Client.Get(ctx, types.NamespacedName{Namespace: req.Namespace, Name: instance.JobName("deploy")}, job)
 	if err == nil {
+		if director != nil && instance.Spec.Cancel && (!Finished(job) || instance.Status.State == v1alpha1.StateCancelling) {
+			log.Info("cancelling job", "job", job.Name)
+			gone, err := CancelJob(r.Client, req.Namespace, director, job)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if task, err := TrackTask(r.Client, req.Namespace, director, job); err == nil && task != nil {
+				instance.Status.CurrentTask = task
+			}
+			instance.Status.Ready, instance.Status.State = false, v1alpha1.StateCancelling
+			if gone {
+				instance.Status.State = v1alpha1.StateCancelled
+			}
+			if err := r.Update(ctx, instance); err != nil {
+				return ctrl.Result{}, err
+			}
+			if !gone {
+				return ctrl.Result{RequeueAfter: TaskPollInterval}, nil
+			}
+			return ctrl.Result{}, nil
+		}
+
+		// create-env jobs hold the state lock until they finish
+		if !instance.ViaDirector() && Finished(job) {
+			if err := ReleaseStateLock(r.Client, instance, job.Name); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+
+		// directors deployed via create-env get upgraded in-place
+		// whenever their ref changes, once they've been deployed.
+		// While that (or a rotation) is going on, it is the upgrade
+		// (or rotation) that says how the director is doing, not the
+		// deploy job.
+		if !instance.ViaDirector() {
+			if _, state := v1alpha1.DetermineReadiness(job); instance.Status.Ref == "" && state == v1alpha1.StateResolved {
+				instance.Status.Ref = job.Annotations[v1alpha1.RefAnnotation]
+				if instance.Status.Ref == "" {
+					// deployed before we started tracking refs
+					instance.Status.Ref = instance.Spec.Ref
+				}
+			}
+			if instance.Status.Ref != "" && instance.Status.Ref != instance.Spec.Ref {
+				return r.upgrade(instance)
+			}
+
+			// credentials get rotated on request, once deployed
+			if instance.Spec.Rotate != nil && instance.Status.Ref != "" && !instance.Status.Rotation.Done(instance.Spec.Rotate.ID) {
+				return r.rotate(instance)
+			}
+		}
+
 		// job exists; we may have gotten a reconcile request based on our watch(es)
 		instance.Status.Ready, instance.Status.State = v1alpha1.DetermineReadiness(job)
+		if director != nil {
+			if task, err := TrackTask(r.Client, req.Namespace, director, job); err != nil {
+				log.Info("unable to track director task", "error", err)
+			} else {
+				instance.Status.CurrentTask = task
+			}
+			if Finished(job) {
+				// find out how things went on the director's side
+				if task := LastTask(r.Client, req.Namespace, director, instance.Name); task != nil {
+					instance.Status.LastTask = task
+				}
+			}
+		}
 		if err := r.Update(ctx, instance); err != nil {
 			return ctrl.Result{}, err
 		}
+		if director != nil && !Finished(job) {
+			// keep an eye on the task while it runs
+			return ctrl.Result{RequeueAfter: TaskPollInterval}, nil
+		}
 
 	} else if !errors.IsNotFound(err) {
 		return ctrl.Result{}, err
 
 	} else {
+		// don't start anything new until un-cancelled
+		if instance.Spec.Cancel {
+			log.Info("cancelled; not starting a new deployment")
+			instance.Status.Ready, instance.Status.State = false, v1alpha1.StateCancelled
+			return ctrl.Result{}, r.Update(ctx, instance)
+		}
+
+		// a director that has been deployed before goes to a new ref
+		// by way of an upgrade, snapshot, checks and all, even if its
+		// deploy job is long gone.
+		if !instance.ViaDirector() && instance.Status.Ref != "" && instance.Status.Ref != instance.Spec.Ref {
+			return r.upgrade(instance)
+		}
+
+		// wait our turn if the director is already busy
+		if director != nil {
+			if queued, err := Queued(r.Client, director, v1alpha1.OperationDeployment); err != nil {
+				return ctrl.Result{}, err
+			} else if queued {
+				log.Info("director is busy; queueing deployment", "director", director.GetName())
+				instance.Status.Ready, instance.Status.State = false, v1alpha1.StateQueued
+				if err := r.Update(ctx, instance); err != nil {
+					return ctrl.Result{}, err
+				}
+				return ctrl.Result{RequeueAfter: QueueRetryAfter}, nil
+			}
+			if locked, task := DeploymentLocked(r.Client, req.Namespace, director, instance.Name); locked {
+				log.Info("deployment is locked on the director; queueing deployment", "director", director.GetName(), "task", task)
+				instance.Status.Ready, instance.Status.State = false, v1alpha1.StateQueued
+				if err := r.Update(ctx, instance); err != nil {
+					return ctrl.Result{}, err
+				}
+				return ctrl.Result{RequeueAfter: QueueRetryAfter}, nil
+			}
+		}
+
+		// restores go first, so that create-env picks up their state
+		if !instance.ViaDirector() {
+			if restore, err := PendingRestore(r.Client, instance); err != nil {
+				return ctrl.Result{}, err
+			} else if restore != nil {
+				log.Info("waiting for director state to be restored", "restore", restore.Name, "state", restore.Status.State)
+				reason, message := "RestorePending", fmt.Sprintf("waiting for BOSHDirectorRestore %s to restore the director state", restore.Name)
+				if restore.Status.State == v1alpha1.StateFailed {
+					reason, message = "RestoreFailed", fmt.Sprintf("BOSHDirectorRestore %s failed; fix it, or delete it to deploy without it", restore.Name)
+				}
+				instance.Status.Conditions.Set(v1alpha1.ConditionBlocked, corev1.ConditionTrue, reason, message)
+				instance.Status.Ready, instance.Status.State = false, v1alpha1.StatePending
+				if err := r.Update(ctx, instance); err != nil {
+					return ctrl.Result{}, err
+				}
+				return ctrl.Result{RequeueAfter: QueueRetryAfter}, nil
+
+			} else if instance.Status.Conditions.IsTrue(v1alpha1.ConditionBlocked) {
+				instance.Status.Conditions.Set(v1alpha1.ConditionBlocked, corev1.ConditionFalse, "Restored", "no restores of the director are pending")
+			}
+		}
+
+		// only one job at a time gets to touch the create-env state
+		if !instance.ViaDirector() {
+			if ok, holder, err := AcquireStateLock(r.Client, r.Scheme, instance, instance.JobName("deploy")); err != nil {
+				return ctrl.Result{}, err
+			} else if !ok {
+				log.Info("director state is locked; queueing deployment", "holder", holder)
+				instance.Status.Ready, instance.Status.State = false, v1alpha1.StateQueued
+				if err := r.Update(ctx, instance); err != nil {
+					return ctrl.Result{}, err
+				}
+				return ctrl.Result{RequeueAfter: QueueRetryAfter}, nil
+			}
+		}
+
 		instance.Status.Ready, instance.Status.State = v1alpha1.DetermineReadiness(nil)
 		if err := r.Update(ctx, instance); err != nil {
 			return ctrl.Result{}, err
@@ -190,18 +339,458 @@ func (r *BOSHDeploymentReconciler) Reconcile(req ctrl.Request) (ctrl.Result, err
 		if err := controllerutil.SetControllerReference(instance, job, r.Scheme); err != nil {
 			return ctrl.Result{}, err
 		}
-		if err := r.Client.Create(ctx, job); err != nil {
+		if director == nil {
+			if err := r.Client.Create(ctx, job); err != nil {
+				return ctrl.Result{}, err
+			}
+		} else if admitted, err := CreateQueued(r.Client, director, v1alpha1.OperationDeployment, job); err != nil {
 			return ctrl.Result{}, err
+		} else if !admitted {
+			log.Info("director got busy; queueing deployment", "director", director.GetName())
+			instance.Status.Ready, instance.Status.State = false, v1alpha1.StateQueued
+			if err := r.Update(ctx, instance); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{RequeueAfter: QueueRetryAfter}, nil
 		}
 
 		// job created.
 		return ctrl.Result{}, nil
 	}
 
+	// keep tabs on what is actually on the director
+	if !instance.ViaDirector() && instance.Status.State == v1alpha1.StateResolved {
+		if due := InventoryDue(instance.Status.Inventory); due > 0 {
+			return ctrl.Result{RequeueAfter: due}, nil
+		}
+
+		log.Info("taking director inventory")
+		instance.Status.Inventory = Inventory(r.Client, instance.Namespace, instance.SecretsName())
+		if err := r.Update(ctx, instance); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: InventoryInterval}, nil
+	}
+
 	// job exists; no need to requeue the reconciliation
 	return ctrl.Result{}, nil
 }
 
+// upgrade rolls a BOSH director deployed via create-env forward to the
+// ref in its spec, by way of an upgrade Job that snapshots the director
+// state and checks the director before and after the create-env.
+func (r *BOSHDeploymentReconciler) upgrade(instance *v1alpha1.BOSHDeployment) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("boshdeployment", types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name})
+
+	if instance.Status.Upgrade == nil || instance.Status.Upgrade.To != instance.Spec.Ref {
+		instance.Status.Upgrade = &v1alpha1.UpgradeStatus{
+			From: instance.Status.Ref,
+			To:   instance.Spec.Ref,
+		}
+	}
+
+	log.Info("checking for upgrade job", "job", instance.UpgradeJobName())
+	job := &batchv1.Job{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: instance.UpgradeJobName()}, job)
+	if err == nil {
+		_, instance.Status.Upgrade.State = v1alpha1.DetermineReadiness(job)
+		instance.Status.Ready, instance.Status.State = v1alpha1.DetermineReadiness(job)
+		if instance.Status.State == v1alpha1.StateResolved {
+			instance.Status.Ref = instance.Status.Upgrade.To
+		}
+		if err := r.Update(ctx, instance); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		if Finished(job) {
+			if err := ReleaseStateLock(r.Client, instance, job.Name); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{}, nil
+
+	} else if !errors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+
+	// only one job at a time gets to touch the create-env state
+	if ok, holder, err := AcquireStateLock(r.Client, r.Scheme, instance, instance.UpgradeJobName()); err != nil {
+		return ctrl.Result{}, err
+	} else if !ok {
+		log.Info("director state is locked; queueing upgrade", "holder", holder)
+		instance.Status.Upgrade.State = v1alpha1.StateQueued
+		instance.Status.Ready, instance.Status.State = false, v1alpha1.StateQueued
+		if err := r.Update(ctx, instance); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: QueueRetryAfter}, nil
+	}
+
+	instance.Status.Upgrade.State = v1alpha1.StatePending
+	instance.Status.Ready, instance.Status.State = v1alpha1.DetermineReadiness(nil)
+	if err := r.Update(ctx, instance); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	log.Info("creating upgrade job", "job", instance.UpgradeJobName(), "from", instance.Status.Upgrade.From, "to", instance.Status.Upgrade.To)
+	job = instance.UpgradeJob()
+	if err := r.ResolveVariableSources(instance, job); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := controllerutil.SetControllerReference(instance, job, r.Scheme); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := r.Client.Create(ctx, job); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// rotate walks a BOSH director deployed via create-env through the
+// rotation of (some of) its credentials: regenerate them and redeploy,
+// re-run everything that was done via the director, and then stop
+// trusting any CAs that were rotated out.
+func (r *BOSHDeploymentReconciler) rotate(instance *v1alpha1.BOSHDeployment) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("boshdeployment", types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name})
+
+	rotation := instance.Status.Rotation
+	if rotation == nil || rotation.ID != instance.Spec.Rotate.ID {
+		rotation = &v1alpha1.RotationStatus{
+			ID:        instance.Spec.Rotate.ID,
+			Variables: instance.Spec.Rotate.Variables,
+			Phase:     v1alpha1.RotationPhasePending,
+		}
+		instance.Status.Rotation = rotation
+	}
+	if rotation.Phase != v1alpha1.RotationPhasePending {
+		instance.Status.Ready, instance.Status.State = false, v1alpha1.StateResolving
+	}
+
+	switch rotation.Phase {
+	case v1alpha1.RotationPhasePending:
+		// don't pull the credentials out from under running jobs
+		jobs := &batchv1.JobList{}
+		if err := r.Client.List(ctx, jobs, client.InNamespace(instance.Namespace), client.MatchingLabels(instance.DirectorLabels())); err != nil {
+			return ctrl.Result{}, err
+		}
+		for _, job := range jobs.Items {
+			if !Finished(&job) {
+				log.Info("director is busy; queueing rotation", "job", job.Name)
+				instance.Status.Ready, instance.Status.State = false, v1alpha1.StateQueued
+				if err := r.Update(ctx, instance); err != nil {
+					return ctrl.Result{}, err
+				}
+				return ctrl.Result{RequeueAfter: QueueRetryAfter}, nil
+			}
+		}
+
+		// only one job at a time gets to touch the create-env state
+		if ok, holder, err := AcquireStateLock(r.Client, r.Scheme, instance, instance.RotationJobName()); err != nil {
+			return ctrl.Result{}, err
+		} else if !ok {
+			log.Info("director state is locked; queueing rotation", "holder", holder)
+			instance.Status.Ready, instance.Status.State = false, v1alpha1.StateQueued
+			if err := r.Update(ctx, instance); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{RequeueAfter: QueueRetryAfter}, nil
+		}
+
+		log.Info("creating rotation job", "job", instance.RotationJobName(), "variables", rotation.Variables)
+		job := instance.RotationJob()
+		if err := r.ResolveVariableSources(instance, job); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := controllerutil.SetControllerReference(instance, job, r.Scheme); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.Client.Create(ctx, job); err != nil && !errors.IsAlreadyExists(err) {
+			return ctrl.Result{}, err
+		}
+
+		rotation.Begin(v1alpha1.RotationPhaseRedeploying, fmt.Sprintf("regenerating %s", strings.Join(rotation.Variables, ", ")))
+		instance.Status.Ready, instance.Status.State = false, v1alpha1.StateResolving
+		return ctrl.Result{}, r.Update(ctx, instance)
+
+	case v1alpha1.RotationPhaseRedeploying:
+		job := &batchv1.Job{}
+		err := r.Client.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: instance.RotationJobName()}, job)
+		if errors.IsNotFound(err) {
+			rotation.Finish(v1alpha1.StateFailed, fmt.Sprintf("rotation job %s disappeared", instance.RotationJobName()))
+			instance.Status.Ready, instance.Status.State = false, v1alpha1.StateFailed
+			return ctrl.Result{}, r.Update(ctx, instance)
+		} else if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !Finished(job) {
+			// the job watch will let us know
+			return ctrl.Result{}, r.Update(ctx, instance)
+		}
+		if err := ReleaseStateLock(r.Client, instance, job.Name); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		if _, state := v1alpha1.DetermineReadiness(job); state != v1alpha1.StateResolved {
+			log.Info("rotation job failed", "job", job.Name)
+			rotation.Finish(v1alpha1.StateFailed, fmt.Sprintf("rotation job %s failed", job.Name))
+			instance.Status.Ready, instance.Status.State = false, v1alpha1.StateFailed
+			return ctrl.Result{}, r.Update(ctx, instance)
+		}
+		rotation.Finish(v1alpha1.StateResolved, "")
+
+		// everything that went through the director with the old
+		// credentials gets done again, with the new ones; deleting the
+		// Jobs has their controllers re-create them.
+		jobs := &batchv1.JobList{}
+		if err := r.Client.List(ctx, jobs, client.InNamespace(instance.Namespace), client.MatchingLabels(instance.DirectorLabels())); err != nil {
+			return ctrl.Result{}, err
+		}
+		rotation.Dependents = nil
+		background := metav1.DeletePropagationBackground
+		for _, job := range jobs.Items {
+			switch job.Labels[v1alpha1.OperationLabel] {
+			case v1alpha1.OperationDeployment, v1alpha1.OperationStemcell, v1alpha1.OperationConfig:
+			default:
+				continue
+			}
+			if rerun, err := r.rerunnable(&job); err != nil {
+				return ctrl.Result{}, err
+			} else if !rerun {
+				continue
+			}
+			log.Info("re-running dependent job", "job", job.Name)
+			if err := r.Client.Delete(ctx, &job, &client.DeleteOptions{PropagationPolicy: &background}); err != nil && !errors.IsNotFound(err) {
+				return ctrl.Result{}, err
+			}
+			rotation.Dependents = append(rotation.Dependents, job.Name)
+		}
+		rotation.Begin(v1alpha1.RotationPhaseDependents, fmt.Sprintf("re-running %d dependent job(s)", len(rotation.Dependents)))
+		if err := r.Update(ctx, instance); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: QueueRetryAfter}, nil
+
+	case v1alpha1.RotationPhaseDependents:
+		failed := []string{}
+		for _, name := range rotation.Dependents {
+			job := &batchv1.Job{}
+			err := r.Client.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: name}, job)
+			if errors.IsNotFound(err) || (err == nil && !Finished(job)) {
+				log.Info("waiting on dependent job", "job", name)
+				return ctrl.Result{RequeueAfter: QueueRetryAfter}, r.Update(ctx, instance)
+			} else if err != nil {
+				return ctrl.Result{}, err
+			}
+			if _, state := v1alpha1.DetermineReadiness(job); state != v1alpha1.StateResolved {
+				failed = append(failed, name)
+			}
+		}
+		if len(failed) > 0 {
+			rotation.Finish(v1alpha1.StateFailed, fmt.Sprintf("dependent job(s) failed: %s", strings.Join(failed, ", ")))
+			instance.Status.Ready, instance.Status.State = false, v1alpha1.StateFailed
+			return ctrl.Result{}, r.Update(ctx, instance)
+		}
+		rotation.Finish(v1alpha1.StateResolved, "")
+		rotation.Begin(v1alpha1.RotationPhaseFinalizing, "dropping previous CA certificates")
+		if err := r.Update(ctx, instance); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{Requeue: true}, nil
+
+	case v1alpha1.RotationPhaseFinalizing:
+		// the rotation job publishes the new CA first, followed by
+		// any that it rotated out; now that everything has moved on to
+		// the new credentials, the old CAs can go.
+		secret := &corev1.Secret{}
+		if err := r.Client.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: instance.SecretsName()}, secret); err != nil {
+			return ctrl.Result{}, err
+		}
+		if ca, rest := pem.Decode(secret.Data["ca"]); ca != nil && len(bytes.TrimSpace(rest)) > 0 {
+			log.Info("dropping previous CA certificates", "secret", secret.Name)
+			secret.Data["ca"] = pem.EncodeToMemory(ca)
+			if err := r.Client.Update(ctx, secret); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+
+		rotation.Finish(v1alpha1.StateResolved, "")
+		rotation.Phase = v1alpha1.RotationPhaseComplete
+		instance.Status.Ready, instance.Status.State = true, v1alpha1.StateResolved
+		return ctrl.Result{}, r.Update(ctx, instance)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// rerunnable returns true if job puts something on the director (i.e.
+// it is an upload, a config update, or a deploy), on behalf of
+// something that is not itself being deleted.  Those are the Jobs that
+// a rotation re-runs; Jobs that take things off the director again
+// (teardowns and deletes) are left be, as are Jobs whose owner would
+// not re-create them.
+func (r *BOSHDeploymentReconciler) rerunnable(job *batchv1.Job) (bool, error) {
+	rerun := false
+	for _, prefix := range []string{"upload-", "update-config-", "deploy-"} {
+		if strings.HasPrefix(job.Name, prefix) {
+			rerun = true
+			break
+		}
+	}
+	if !rerun {
+		return false, nil
+	}
+
+	ref := metav1.GetControllerOf(job)
+	if ref == nil {
+		return false, nil
+	}
+	owner := &unstructured.Unstructured{}
+	owner.SetAPIVersion(ref.APIVersion)
+	owner.SetKind(ref.Kind)
+	if err := r.Client.Get(context.Background(), types.NamespacedName{Namespace: job.Namespace, Name: ref.Name}, owner); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return owner.GetDeletionTimestamp().IsZero(), nil
+}
+
+// finalize tears down the deployment (or director) if its deletion policy
+// says to, and lets go of the BOSHDeployment once that is done.
+func (r *BOSHDeploymentReconciler) finalize(instance *v1alpha1.BOSHDeployment) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("boshdeployment", types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name})
+
+	if !instance.Spec.DeletionPolicy.Deletes() {
+		if instance.Spec.DeletionPolicy == v1alpha1.DeletionPolicyRetain && instance.UsesStateVolume() {
+			// cut the state volume loose, so it doesn't get garbage-collected
+			log.Info("retaining persistent state volume", "pvc", instance.StateVolumeName())
+			pvc := &corev1.PersistentVolumeClaim{}
+			err := r.Client.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: instance.StateVolumeName()}, pvc)
+			if err == nil {
+				pvc.OwnerReferences = nil
+				if err := r.Client.Update(ctx, pvc); err != nil {
+					return ctrl.Result{}, err
+				}
+			} else if !errors.IsNotFound(err) {
+				return ctrl.Result{}, err
+			}
+		}
+
+		log.Info("leaving deployment in place", "policy", instance.Spec.DeletionPolicy)
+		return ctrl.Result{}, r.release(instance)
+	}
+
+	log.Info("checking for teardown job", "job", instance.JobName("teardown"))
+	job := &batchv1.Job{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: instance.JobName("teardown")}, job)
+	if err == nil {
+		instance.Status.Ready, instance.Status.State = v1alpha1.DetermineReadiness(job)
+		if err := r.Update(ctx, instance); err != nil {
+			return ctrl.Result{}, err
+		}
+		if !Finished(job) {
+			return ctrl.Result{}, nil
+		}
+
+		if !instance.ViaDirector() {
+			if err := ReleaseStateLock(r.Client, instance, job.Name); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		if instance.Status.State == v1alpha1.StateFailed {
+			// leave the finalizer in place; someone has to look into this
+			// (and either delete the teardown job to retry, or orphan us)
+			log.Info("teardown failed; not letting go of deployment", "job", job.Name)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, r.release(instance)
+
+	} else if !errors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+
+	var director v1alpha1.Director
+	if instance.ViaDirector() {
+		director, err = LookupDirector(r.Client, r.Scheme, instance.Namespace, instance.Spec.Director, instance.Spec.ClusterDirector)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if director == nil {
+			// nothing left to tear down via
+			log.Info("director not found; leaving deployment in place", "director", instance.Spec.Director, "cluster-director", instance.Spec.ClusterDirector)
+			return ctrl.Result{}, r.release(instance)
+		}
+
+		if queued, err := Queued(r.Client, director, v1alpha1.OperationDeployment); err != nil {
+			return ctrl.Result{}, err
+		} else if queued {
+			log.Info("director is busy; queueing teardown", "director", director.GetName())
+			instance.Status.Ready, instance.Status.State = false, v1alpha1.StateQueued
+			if err := r.Update(ctx, instance); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{RequeueAfter: QueueRetryAfter}, nil
+		}
+		if locked, task := DeploymentLocked(r.Client, instance.Namespace, director, instance.Name); locked {
+			log.Info("deployment is locked on the director; queueing teardown", "director", director.GetName(), "task", task)
+			instance.Status.Ready, instance.Status.State = false, v1alpha1.StateQueued
+			if err := r.Update(ctx, instance); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{RequeueAfter: QueueRetryAfter}, nil
+		}
+
+	} else {
+		if err := EnsureStateKey(r.Client, instance); err != nil {
+			return ctrl.Result{}, err
+		}
+		if ok, holder, err := AcquireStateLock(r.Client, r.Scheme, instance, instance.JobName("teardown")); err != nil {
+			return ctrl.Result{}, err
+		} else if !ok {
+			log.Info("director state is locked; queueing teardown", "holder", holder)
+			instance.Status.Ready, instance.Status.State = false, v1alpha1.StateQueued
+			if err := r.Update(ctx, instance); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{RequeueAfter: QueueRetryAfter}, nil
+		}
+	}
+
+	log.Info("creating teardown job", "job", instance.JobName("teardown"))
+	job = instance.TeardownJob()
+	if err := r.ResolveVariableSources(instance, job); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := controllerutil.SetControllerReference(instance, job, r.Scheme); err != nil {
+		return ctrl.Result{}, err
+	}
+	if director == nil {
+		return ctrl.Result{}, r.Client.Create(ctx, job)
+	}
+	if admitted, err := CreateQueued(r.Client, director, v1alpha1.OperationDeployment, job); err != nil {
+		return ctrl.Result{}, err
+	} else if !admitted {
+		log.Info("director got busy; queueing teardown", "director", director.GetName())
+		instance.Status.Ready, instance.Status.State = false, v1alpha1.StateQueued
+		if err := r.Update(ctx, instance); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: QueueRetryAfter}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// release removes our finalizer, so that Kubernetes can finish deleting
+// the BOSHDeployment (and garbage-collect whatever it still owns).
+func (r *BOSHDeploymentReconciler) release(instance *v1alpha1.BOSHDeployment) error {
+	controllerutil.RemoveFinalizer(instance, Finalizer)
+	return r.Update(context.Background(), instance)
+}
+
 func (r *BOSHDeploymentReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.BOSHDeployment{}).
@@ -219,6 +808,19 @@ func HasFinalizer(o metav1.Object, finalizer string) bool {
 	return false
 }
 
+// PredatesFinalizer pins the deletion policy of an object that is only
+// now getting its finalizer, but has been reconciled before (it has a
+// state), to Orphan.  Such objects were created before Gluon tore
+// things down on delete, and `kubectl delete` shouldn't start running
+// delete-deployment (or delete-env) against them without being asked.
+func PredatesFinalizer(state string, policy *v1alpha1.DeletionPolicy) bool {
+	if state == "" || *policy != "" {
+		return false
+	}
+	*policy = v1alpha1.DeletionPolicyOrphan
+	return true
+}
+
 func (r *BOSHDeploymentReconciler) ResolveVariableSources(bd *v1alpha1.BOSHDeployment, job *batchv1.Job) error {
 	ctx := context.Background()
 
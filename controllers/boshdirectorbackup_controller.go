@@ -0,0 +1,187 @@
+/*
+Gluon - BOSH / CF Orchestration via Kuberenetes API(s)
+
+Copyright (c) 2020 James Hunt
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to
+deal in the Software without restriction, including without limitation the
+rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+sell copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software..
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+IN THE SOFTWARE.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	v1alpha1 "github.com/starkandwayne/gluon-controller/api/v1alpha1"
+)
+
+// BOSHDirectorBackupReconciler reconciles a BOSHDirectorBackup object
+type BOSHDirectorBackupReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=gluon.starkandwayne.com,resources=boshdirectorbackups,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=gluon.starkandwayne.com,resources=boshdirectorbackups/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=batch,resources=cronjobs,verbs=get;list;watch;create;update;patch;delete
+
+func (r *BOSHDirectorBackupReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("boshdirectorbackup", req.NamespacedName)
+
+	// fetch the BOSHDirectorBackup instance
+	instance := &v1alpha1.BOSHDirectorBackup{}
+	err := r.Client.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	director := &v1alpha1.BOSHDeployment{}
+	err = r.Client.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: instance.Spec.Director}, director)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			log.Info("director not found; waiting for it", "director", instance.Spec.Director)
+			return ctrl.Result{RequeueAfter: QueueRetryAfter}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	if director.ViaDirector() {
+		// only create-env directors have state for us to back up
+		log.Info("not a create-env director; nothing to back up", "director", director.Name)
+		instance.Status.Ready, instance.Status.State = false, v1alpha1.StateFailed
+		return ctrl.Result{}, r.Update(ctx, instance)
+	}
+
+	// (backups decrypt the saved vars-store, so they need its key)
+	if err := EnsureStateKey(r.Client, director); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if instance.Spec.Schedule != "" {
+		return r.scheduled(instance, director)
+	}
+
+	job := &batchv1.Job{}
+	err = r.Client.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: instance.JobName()}, job)
+	if err == nil {
+		instance.Status.Ready, instance.Status.State = v1alpha1.DetermineReadiness(job)
+		if instance.Status.State == v1alpha1.StateResolved && job.Status.CompletionTime != nil {
+			instance.Status.LastBackupTime = job.Status.CompletionTime
+		}
+		return ctrl.Result{}, r.Update(ctx, instance)
+
+	} else if !errors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+
+	instance.Status.Ready, instance.Status.State = v1alpha1.DetermineReadiness(nil)
+	if err := r.Update(ctx, instance); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	job = instance.Job(director)
+	if err := controllerutil.SetControllerReference(instance, job, r.Scheme); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, r.Client.Create(ctx, job)
+}
+
+// scheduled keeps the backup CronJob in line with the BOSHDirectorBackup.
+func (r *BOSHDirectorBackupReconciler) scheduled(instance *v1alpha1.BOSHDirectorBackup, director *v1alpha1.BOSHDeployment) (ctrl.Result, error) {
+	ctx := context.Background()
+
+	want := instance.CronJob(director)
+	if err := controllerutil.SetControllerReference(instance, want, r.Scheme); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	cron := &batchv1beta1.CronJob{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: want.Namespace, Name: want.Name}, cron)
+	if errors.IsNotFound(err) {
+		if err := r.Client.Create(ctx, want); err != nil {
+			return ctrl.Result{}, err
+		}
+		cron = want
+
+	} else if err != nil {
+		return ctrl.Result{}, err
+
+	} else {
+		cron.Spec = want.Spec
+		if err := r.Client.Update(ctx, cron); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	instance.Status.Ready, instance.Status.State = true, v1alpha1.StateResolved
+	if last, err := r.lastSuccess(cron); err != nil {
+		return ctrl.Result{}, err
+	} else if last != nil {
+		instance.Status.LastBackupTime = last
+	}
+	return ctrl.Result{}, r.Update(ctx, instance)
+}
+
+// lastSuccess returns when the most recent successful backup Job that
+// cron started completed, if any did.  (The CronJob itself only knows
+// when it last started one, successful or not.)
+func (r *BOSHDirectorBackupReconciler) lastSuccess(cron *batchv1beta1.CronJob) (*metav1.Time, error) {
+	jobs := &batchv1.JobList{}
+	if err := r.Client.List(context.Background(), jobs, client.InNamespace(cron.Namespace)); err != nil {
+		return nil, err
+	}
+
+	var last *metav1.Time
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if owner := metav1.GetControllerOf(job); owner == nil || owner.UID != cron.UID {
+			continue
+		}
+		if _, state := v1alpha1.DetermineReadiness(job); state != v1alpha1.StateResolved || job.Status.CompletionTime == nil {
+			continue
+		}
+		if last == nil || last.Before(job.Status.CompletionTime) {
+			last = job.Status.CompletionTime
+		}
+	}
+	return last, nil
+}
+
+func (r *BOSHDirectorBackupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.BOSHDirectorBackup{}).
+		Owns(&batchv1.Job{}).
+		Owns(&batchv1beta1.CronJob{}).
+		Complete(r)
+}
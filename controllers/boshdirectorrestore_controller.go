@@ -0,0 +1,154 @@
+/*
+Gluon - BOSH / CF Orchestration via Kuberenetes API(s)
+
+Copyright (c) 2020 James Hunt
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to
+deal in the Software without restriction, including without limitation the
+rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+sell copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software..
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+IN THE SOFTWARE.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	batchv1 "k8s.io/api/batch/v1"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	v1alpha1 "github.com/starkandwayne/gluon-controller/api/v1alpha1"
+)
+
+// BOSHDirectorRestoreReconciler reconciles a BOSHDirectorRestore object
+type BOSHDirectorRestoreReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=gluon.starkandwayne.com,resources=boshdirectorrestores,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=gluon.starkandwayne.com,resources=boshdirectorrestores/status,verbs=get;update;patch
+
+func (r *BOSHDirectorRestoreReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("boshdirectorrestore", req.NamespacedName)
+
+	// fetch the BOSHDirectorRestore instance
+	instance := &v1alpha1.BOSHDirectorRestore{}
+	err := r.Client.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	director := &v1alpha1.BOSHDeployment{}
+	err = r.Client.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: instance.Spec.Director}, director)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			log.Info("director not found; waiting for it", "director", instance.Spec.Director)
+			return ctrl.Result{RequeueAfter: QueueRetryAfter}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	if director.ViaDirector() {
+		log.Info("not a create-env director; nothing to restore", "director", director.Name)
+		instance.Status.Ready, instance.Status.State = false, v1alpha1.StateFailed
+		return ctrl.Result{}, r.Update(ctx, instance)
+	}
+
+	job := &batchv1.Job{}
+	err = r.Client.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: instance.JobName()}, job)
+	if err == nil {
+		instance.Status.Ready, instance.Status.State = v1alpha1.DetermineReadiness(job)
+		if err := r.Update(ctx, instance); err != nil {
+			return ctrl.Result{}, err
+		}
+		if Finished(job) {
+			return ctrl.Result{}, ReleaseStateLock(r.Client, director, job.Name)
+		}
+		return ctrl.Result{}, nil
+
+	} else if !errors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+
+	// (restores save the vars-store off again, encrypted)
+	if err := EnsureStateKey(r.Client, director); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// restoring state out from under a running create-env would be bad.
+	if ok, holder, err := AcquireStateLock(r.Client, r.Scheme, director, instance.JobName()); err != nil {
+		return ctrl.Result{}, err
+	} else if !ok {
+		log.Info("director state is locked; queueing restore", "holder", holder)
+		instance.Status.Ready, instance.Status.State = false, v1alpha1.StateQueued
+		if err := r.Update(ctx, instance); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: QueueRetryAfter}, nil
+	}
+
+	instance.Status.Ready, instance.Status.State = v1alpha1.DetermineReadiness(nil)
+	if err := r.Update(ctx, instance); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	job = instance.Job(director)
+	if err := controllerutil.SetControllerReference(instance, job, r.Scheme); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, r.Client.Create(ctx, job)
+}
+
+// PendingRestore returns a BOSHDirectorRestore of the director bd that
+// has yet to succeed, if there is one.  Create-env directors hold off
+// deploying until then, so that a rebuilt cluster gets back the
+// director it had, rather than a new, empty one.  That includes failed
+// restores (someone has to either fix them, or delete them), but not
+// cancelled ones.
+func PendingRestore(c client.Client, bd *v1alpha1.BOSHDeployment) (*v1alpha1.BOSHDirectorRestore, error) {
+	restores := &v1alpha1.BOSHDirectorRestoreList{}
+	if err := c.List(context.Background(), restores, client.InNamespace(bd.Namespace)); err != nil {
+		return nil, err
+	}
+	for i, restore := range restores.Items {
+		if restore.Spec.Director != bd.Name || !restore.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if restore.Status.State != v1alpha1.StateResolved && restore.Status.State != v1alpha1.StateCancelled {
+			return &restores.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *BOSHDirectorRestoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.BOSHDirectorRestore{}).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
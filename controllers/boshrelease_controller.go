@@ -0,0 +1,506 @@
+/*
+Gluon - BOSH / CF Orchestration via Kuberenetes API(s)
+
+Copyright (c) 2020 James Hunt
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to
+deal in the Software without restriction, including without limitation the
+rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+sell copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software..
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+IN THE SOFTWARE.
+*/
+
+package controllers
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	v1alpha1 "github.com/starkandwayne/gluon-controller/api/v1alpha1"
+)
+
+const ReleaseFinalizer = "boshrelease.gluon.starkandwayne.com"
+
+// BOSHReleaseReconciler reconciles a BOSHRelease object
+type BOSHReleaseReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=gluon.starkandwayne.com,resources=boshreleases,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=gluon.starkandwayne.com,resources=boshreleases/status,verbs=get;update;patch
+
+func (r *BOSHReleaseReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("boshrelease", req.NamespacedName)
+
+	// fetch the BOSHRelease instance
+	instance := &v1alpha1.BOSHRelease{}
+	err := r.Client.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// that's ok, maybe someone got cold feet and deleted it.
+			return ctrl.Result{}, nil
+		}
+		// something else went wrong...
+		return ctrl.Result{}, err
+	}
+
+	// register our finalizer, or act on it if we are being deleted
+	if instance.ObjectMeta.DeletionTimestamp.IsZero() {
+		if !HasFinalizer(instance, ReleaseFinalizer) {
+			controllerutil.AddFinalizer(instance, ReleaseFinalizer)
+			if err := r.Update(ctx, instance); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	} else {
+		if HasFinalizer(instance, ReleaseFinalizer) {
+			return r.finalize(instance)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// check to see if our dependencies are resolved
+	log.Info("checking dependencies")
+	if ok, info, err := instance.Dependencies.Resolved(r.Client, instance.Namespace); !ok {
+		if err != nil {
+			log.Info("failed to determine if dependencies are resolved", "dependency", info, "error", err)
+		} else {
+			log.Info("dependencies not yet resolved", "dependency", info)
+		}
+		return instance.Dependencies.Requeue(), err
+	}
+
+	// fetch through the artifact cache, if there is one
+	instance.Status.CacheURL = ""
+	if instance.Spec.Cache != "" {
+		url, err := ArtifactCacheURL(r.Client, instance.Namespace, instance.Spec.Cache)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if url == "" {
+			log.Info("artifact cache not ready (yet)", "cache", instance.Spec.Cache)
+			instance.Status.Ready, instance.Status.State = false, v1alpha1.StatePending
+			if err := r.Update(ctx, instance); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{RequeueAfter: QueueRetryAfter}, nil
+		}
+		instance.Status.CacheURL = url
+	}
+
+	directors, err := TargetDirectors(r.Client, r.Scheme, instance.Namespace, instance.Spec.Director, instance.Spec.ClusterDirector, instance.Spec.DirectorTargets)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if len(directors) == 0 {
+		log.Info("no directors to upload release to (yet)")
+		instance.Status.Ready, instance.Status.State = false, v1alpha1.StatePending
+		return ctrl.Result{}, r.Update(ctx, instance)
+	}
+
+	// upload the release to each director (the Jobs run side by side)
+	result := ctrl.Result{}
+	targeted := []v1alpha1.ReleaseDirectorStatus{}
+	instance.Status.CurrentTask = nil
+	for _, director := range directors {
+		status := instance.DirectorStatus(director)
+		requeue, err := r.upload(instance, director, status)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if requeue > 0 && (result.RequeueAfter == 0 || requeue < result.RequeueAfter) {
+			result.RequeueAfter = requeue
+		}
+		if status.CurrentTask != nil && instance.Status.CurrentTask == nil {
+			instance.Status.CurrentTask = status.CurrentTask
+		}
+		targeted = append(targeted, *status)
+	}
+
+	// (directors we have already uploaded to, but no longer target,
+	// are only forgotten about once the release is deleted from them)
+	all := targeted
+	for _, s := range instance.Status.Directors {
+		found := false
+		for _, t := range targeted {
+			if s.DirectorStatus.Director == t.DirectorStatus.Director && s.ClusterDirector == t.ClusterDirector {
+				found = true
+				break
+			}
+		}
+		if !found && s.Uploaded != nil {
+			all = append(all, s)
+		}
+	}
+	instance.Status.Directors = all
+
+	statuses := []v1alpha1.DirectorStatus{}
+	for _, s := range targeted {
+		statuses = append(statuses, s.DirectorStatus)
+	}
+	instance.Status.Ready, instance.Status.State = v1alpha1.Aggregate(statuses)
+	if err := r.Update(ctx, instance); err != nil {
+		return ctrl.Result{}, err
+	}
+	return result, nil
+}
+
+// upload makes sure the release gets uploaded to one director, keeping
+// track of how that is going in status.  It returns how soon to check
+// back in, if it needs to.
+func (r *BOSHReleaseReconciler) upload(instance *v1alpha1.BOSHRelease, director v1alpha1.Director, status *v1alpha1.ReleaseDirectorStatus) (time.Duration, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("boshrelease", types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}, "director", director.GetName())
+
+	// each revision of the release gets its own upload Job; an upload
+	// of the previous revision that is still running is left to finish
+	// before the new one starts.
+	revision := instance.Revision()
+	if previous := status.Revision; previous != revision {
+		superseded := &batchv1.Job{}
+		err := r.Client.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: instance.RevisionJobName(director, previous)}, superseded)
+		if err == nil && !Finished(superseded) {
+			log.Info("waiting for upload of previous revision to finish", "job", superseded.Name, "revision", previous)
+			status.Ready, status.State = false, v1alpha1.StateQueued
+			return TaskPollInterval, nil
+
+		} else if err == nil && superseded.DeletionTimestamp.IsZero() {
+			log.Info("cleaning up upload job for previous revision", "job", superseded.Name, "revision", previous)
+			background := metav1.DeletePropagationBackground
+			if err := r.Client.Delete(ctx, superseded, &client.DeleteOptions{PropagationPolicy: &background}); err != nil && !errors.IsNotFound(err) {
+				return 0, err
+			}
+
+		} else if err != nil && !errors.IsNotFound(err) {
+			return 0, err
+		}
+	}
+
+	job := &batchv1.Job{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: instance.JobName(director)}, job)
+	if err == nil {
+		if instance.Spec.Cancel && (!Finished(job) || status.State == v1alpha1.StateCancelling) {
+			log.Info("cancelling job", "job", job.Name)
+			gone, err := CancelJob(r.Client, instance.Namespace, director, job)
+			if err != nil {
+				return 0, err
+			}
+			if task, err := TrackTask(r.Client, instance.Namespace, director, job); err == nil && task != nil {
+				status.CurrentTask = task
+			}
+			status.Ready, status.State = false, v1alpha1.StateCancelling
+			if gone {
+				status.State = v1alpha1.StateCancelled
+				return 0, nil
+			}
+			return TaskPollInterval, nil
+		}
+
+		// job exists; we may have gotten a reconcile request based on our watch(es)
+		status.Ready, status.State = v1alpha1.DetermineReadiness(job)
+		if task, err := TrackTask(r.Client, instance.Namespace, director, job); err != nil {
+			log.Info("unable to track director task", "error", err)
+		} else {
+			status.CurrentTask = task
+		}
+		if !Finished(job) {
+			// keep an eye on the task while it runs
+			return TaskPollInterval, nil
+		}
+
+		// remember what we uploaded, so that we can delete it later
+		if status.State == v1alpha1.StateResolved && status.Uploaded == nil {
+			if uploaded := r.uploaded(instance, director, job); uploaded != nil {
+				log.Info("release uploaded", "name", uploaded.Name, "version", uploaded.Version)
+				uploaded.Digest = job.Annotations[v1alpha1.DigestAnnotation]
+				if uploaded.Digest == "" {
+					// the director fetched (and checked) it itself
+					uploaded.Digest = instance.Digest()
+				}
+				status.Uploaded = uploaded
+			}
+		}
+		return 0, nil
+
+	} else if !errors.IsNotFound(err) {
+		return 0, err
+	}
+
+	// don't start anything new until un-cancelled
+	if instance.Spec.Cancel {
+		log.Info("cancelled; not starting a new release upload")
+		status.Ready, status.State = false, v1alpha1.StateCancelled
+		return 0, nil
+	}
+
+	// wait our turn if the director is already busy
+	if queued, err := Queued(r.Client, director, v1alpha1.OperationRelease); err != nil {
+		return 0, err
+	} else if queued {
+		log.Info("director is busy; queueing release upload")
+		status.Ready, status.State = false, v1alpha1.StateQueued
+		return QueueRetryAfter, nil
+	}
+
+	// create the Job resource, in all of its glory
+	log.Info("creating release upload job", "job", instance.JobName(director))
+	status.Ready, status.State = v1alpha1.DetermineReadiness(nil)
+	job = instance.Job(director)
+	if err := controllerutil.SetControllerReference(instance, job, r.Scheme); err != nil {
+		return 0, err
+	}
+	if admitted, err := CreateQueued(r.Client, director, v1alpha1.OperationRelease, job); err != nil {
+		return 0, err
+	} else if !admitted {
+		log.Info("director got busy; queueing release upload")
+		status.Ready, status.State = false, v1alpha1.StateQueued
+		return QueueRetryAfter, nil
+	}
+
+	// whatever was uploaded before is not what this Job uploads
+	// (though it stays on the director)
+	if status.Revision != revision {
+		status.Uploaded = nil
+	}
+	status.Revision = revision
+
+	// job created.
+	return 0, nil
+}
+
+// upload tasks finish with "Created release 'name/version'"
+var createdRelease = regexp.MustCompile(`release '([^/']+)/([^']+)'`)
+
+// uploaded works out which release the upload Job put on the director;
+// either the spec says, or the upload task's result does.
+func (r *BOSHReleaseReconciler) uploaded(instance *v1alpha1.BOSHRelease, director v1alpha1.Director, job *batchv1.Job) *v1alpha1.UploadedRelease {
+	if instance.Spec.Name != "" && instance.Spec.Version != "" {
+		return &v1alpha1.UploadedRelease{Name: instance.Spec.Name, Version: instance.Spec.Version}
+	}
+
+	id, err := strconv.Atoi(job.Annotations[v1alpha1.TaskAnnotation])
+	if err != nil {
+		return nil
+	}
+	d, err := DirectorClient(r.Client, instance.Namespace, director.SecretsName())
+	if err != nil {
+		return nil
+	}
+	task, err := d.Task(id)
+	if err != nil {
+		return nil
+	}
+
+	m := createdRelease.FindStringSubmatch(task.Result)
+	if m == nil {
+		return nil
+	}
+	return &v1alpha1.UploadedRelease{Name: m[1], Version: m[2]}
+}
+
+// finalize deletes the release from each director it was uploaded to, if
+// its deletion policy says to (and no deployment is still using it
+// there), and lets go of the BOSHRelease once that is done.
+func (r *BOSHReleaseReconciler) finalize(instance *v1alpha1.BOSHRelease) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("boshrelease", types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name})
+
+	if !instance.Spec.DeletionPolicy.Deletes() {
+		log.Info("leaving release on the director(s)", "policy", instance.Spec.DeletionPolicy)
+		return ctrl.Result{}, r.release(instance)
+	}
+
+	// directors drop off the list as we finish with them
+	result := ctrl.Result{}
+	remaining := []v1alpha1.ReleaseDirectorStatus{}
+	for _, status := range instance.Status.Directors {
+		status := status
+		done, requeue, err := r.delete(instance, &status)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !done {
+			remaining = append(remaining, status)
+		}
+		if requeue > 0 && (result.RequeueAfter == 0 || requeue < result.RequeueAfter) {
+			result.RequeueAfter = requeue
+		}
+	}
+	instance.Status.Directors = remaining
+
+	if len(remaining) == 0 {
+		return ctrl.Result{}, r.release(instance)
+	}
+	return result, r.Update(ctx, instance)
+}
+
+// delete removes the release from one director.  It returns true once
+// there is nothing more to be done there.
+func (r *BOSHReleaseReconciler) delete(instance *v1alpha1.BOSHRelease, status *v1alpha1.ReleaseDirectorStatus) (bool, time.Duration, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("boshrelease", types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}, "director", status.DirectorStatus.Director, "cluster-director", status.ClusterDirector)
+
+	director, err := LookupDirector(r.Client, r.Scheme, instance.Namespace, status.DirectorStatus.Director, status.ClusterDirector)
+	if err != nil {
+		return false, 0, err
+	}
+	if director == nil {
+		log.Info("director not found; leaving release in place")
+		return true, 0, nil
+	}
+
+	uploaded := status.Uploaded
+	if uploaded == nil {
+		r.Recorder.Eventf(instance, corev1.EventTypeWarning, "ReleaseNotDeleted",
+			"not deleting release from director %s: unable to tell which release was uploaded", director.GetName())
+		return true, 0, nil
+	}
+
+	job := &batchv1.Job{}
+	err = r.Client.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: instance.DeleteJobName(director)}, job)
+	if err == nil {
+		status.Ready, status.State = v1alpha1.DetermineReadiness(job)
+		if !Finished(job) {
+			return false, 0, nil
+		}
+		if status.State == v1alpha1.StateFailed {
+			// someone may have started using it (or deleted it) in the
+			// meantime
+			if deployed, there, err := r.deployed(instance, director, uploaded); err == nil && !there {
+				log.Info("release is no longer on the director", "name", uploaded.Name, "version", uploaded.Version)
+				return true, 0, nil
+			} else if err == nil && deployed {
+				r.skip(instance, director, uploaded)
+				return true, 0, nil
+			}
+
+			log.Info("delete-release failed; retrying it", "job", job.Name, "retries", status.Retries)
+			r.Recorder.Eventf(instance, corev1.EventTypeWarning, "ReleaseDeleteFailed",
+				"unable to delete release %s/%s from director %s (see job %s); retrying",
+				uploaded.Name, uploaded.Version, director.GetName(), job.Name)
+			retry, err := RetryFailed(r.Client, job, &status.DirectorStatus)
+			return false, retry, err
+		}
+		return true, 0, nil
+
+	} else if !errors.IsNotFound(err) {
+		return false, 0, err
+	}
+
+	// BOSH won't delete releases that deployments are using, and
+	// neither will we.
+	deployed, there, err := r.deployed(instance, director, uploaded)
+	if err != nil {
+		return false, 0, err
+	}
+	if !there {
+		log.Info("release is no longer on the director", "name", uploaded.Name, "version", uploaded.Version)
+		return true, 0, nil
+	}
+	if deployed {
+		r.skip(instance, director, uploaded)
+		return true, 0, nil
+	}
+
+	if queued, err := Queued(r.Client, director, v1alpha1.OperationRelease); err != nil {
+		return false, 0, err
+	} else if queued {
+		log.Info("director is busy; queueing release removal")
+		status.Ready, status.State = false, v1alpha1.StateQueued
+		return false, QueueRetryAfter, nil
+	}
+
+	log.Info("creating delete-release job", "job", instance.DeleteJobName(director))
+	job = instance.DeleteJob(director, uploaded)
+	if err := controllerutil.SetControllerReference(instance, job, r.Scheme); err != nil {
+		return false, 0, err
+	}
+	if admitted, err := CreateQueued(r.Client, director, v1alpha1.OperationRelease, job); err != nil {
+		return false, 0, err
+	} else if !admitted {
+		log.Info("director got busy; queueing release removal")
+		status.Ready, status.State = false, v1alpha1.StateQueued
+		return false, QueueRetryAfter, nil
+	}
+	return false, 0, nil
+}
+
+// deployed asks the director whether the uploaded release is there, and
+// whether any deployment is using it.
+func (r *BOSHReleaseReconciler) deployed(instance *v1alpha1.BOSHRelease, director v1alpha1.Director, uploaded *v1alpha1.UploadedRelease) (bool, bool, error) {
+	d, err := DirectorClient(r.Client, instance.Namespace, director.SecretsName())
+	if err != nil {
+		return false, false, err
+	}
+	releases, err := d.Releases()
+	if err != nil {
+		return false, false, err
+	}
+	for _, rel := range releases {
+		if rel.Name != uploaded.Name {
+			continue
+		}
+		for _, v := range rel.Versions {
+			if strings.TrimSuffix(v.Version, "*") == uploaded.Version {
+				return v.CurrentlyDeployed, true, nil
+			}
+		}
+	}
+	return false, false, nil
+}
+
+// skip reports that the release is being left on a director, because
+// deployments are still using it.
+func (r *BOSHReleaseReconciler) skip(instance *v1alpha1.BOSHRelease, director v1alpha1.Director, uploaded *v1alpha1.UploadedRelease) {
+	r.Log.Info("release is still in use; leaving it on the director",
+		"boshrelease", types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}, "director", director.GetName())
+	r.Recorder.Eventf(instance, corev1.EventTypeWarning, "ReleaseInUse",
+		"not deleting release %s/%s from director %s: still in use by one or more deployments",
+		uploaded.Name, uploaded.Version, director.GetName())
+}
+
+// release removes our finalizer, so that Kubernetes can finish deleting
+// the BOSHRelease (and garbage-collect whatever it still owns).
+func (r *BOSHReleaseReconciler) release(instance *v1alpha1.BOSHRelease) error {
+	controllerutil.RemoveFinalizer(instance, ReleaseFinalizer)
+	return r.Update(context.Background(), instance)
+}
+
+func (r *BOSHReleaseReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.BOSHRelease{}).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
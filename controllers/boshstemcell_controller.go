@@ -26,25 +26,44 @@ package controllers
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
 
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	v1alpha1 "github.com/starkandwayne/gluon-controller/api/v1alpha1"
+	"github.com/starkandwayne/gluon-controller/boshio"
 )
 
+const StemcellFinalizer = "boshstemcell.gluon.starkandwayne.com"
+
+// StemcellIndex is where stemcells given by operating system, version
+// and IaaS get resolved; bosh.io, unless told otherwise.
+var StemcellIndex = boshio.DefaultIndex
+
+// StemcellVerifyInterval is how often Gluon checks that uploaded
+// stemcells are still on their directors.
+var StemcellVerifyInterval = 10 * time.Minute
+
 // BOSHStemcellReconciler reconciles a BOSHStemcell object
 type BOSHStemcellReconciler struct {
 	client.Client
-	Log    logr.Logger
-	Scheme *runtime.Scheme
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
 }
 
 // +kubebuilder:rbac:groups=gluon.starkandwayne.com,resources=boshstemcells,verbs=get;list;watch;create;update;patch;delete
@@ -66,6 +85,24 @@ func (r *BOSHStemcellReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error
 		return ctrl.Result{}, err
 	}
 
+	// register our finalizer, or act on it if we are being deleted
+	if instance.ObjectMeta.DeletionTimestamp.IsZero() {
+		if !HasFinalizer(instance, StemcellFinalizer) {
+			if PredatesFinalizer(instance.Status.State, &instance.Spec.DeletionPolicy) {
+				log.Info("stemcell predates deletion policies; leaving it in place when deleted", "policy", instance.Spec.DeletionPolicy)
+			}
+			controllerutil.AddFinalizer(instance, StemcellFinalizer)
+			if err := r.Update(ctx, instance); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	} else {
+		if HasFinalizer(instance, StemcellFinalizer) {
+			return r.finalize(instance)
+		}
+		return ctrl.Result{}, nil
+	}
+
 	// check to see if our dependencies are resolved
 	log.Info("checking dependencies")
 	if ok, info, err := instance.Dependencies.Resolved(r.Client, instance.Namespace); !ok {
@@ -77,47 +114,511 @@ func (r *BOSHStemcellReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error
 		return instance.Dependencies.Requeue(), err
 	}
 
-	director := &v1alpha1.BOSHDeployment{}
-	err = r.Client.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: instance.Spec.Director}, director)
+	// stemcells given by operating system / version / IaaS have to be
+	// tracked down in the stemcell index first
+	if instance.FromIndex() {
+		index := boshio.New(StemcellIndex)
+		if resolved := instance.Status.Resolved; resolved == nil || resolved.Query != instance.IndexQuery() || resolved.Index != index.URL {
+			log.Info("resolving stemcell", "index", index.URL, "query", instance.IndexQuery())
+			stemcell, err := index.Resolve(boshio.Query{
+				OS:      instance.Spec.OS,
+				Version: instance.Spec.Version,
+				IaaS:    instance.Spec.IaaS,
+				Light:   instance.Spec.Light,
+			})
+			if err != nil {
+				log.Info("unable to resolve stemcell", "index", index.URL, "query", instance.IndexQuery(), "error", err)
+				instance.Status.Ready, instance.Status.State = false, v1alpha1.StateFailed
+				if err := r.Update(ctx, instance); err != nil {
+					return ctrl.Result{}, err
+				}
+				return ctrl.Result{RequeueAfter: time.Minute}, nil
+			}
+
+			log.Info("resolved stemcell", "name", stemcell.Name, "version", stemcell.Version, "url", stemcell.URL)
+			instance.Status.Resolved = &v1alpha1.ResolvedStemcell{
+				Name:    stemcell.Name,
+				Version: stemcell.Version,
+				URL:     stemcell.URL,
+				SHA1:    stemcell.SHA1,
+				SHA256:  stemcell.SHA256,
+				Index:   index.URL,
+				Query:   instance.IndexQuery(),
+			}
+			if err := r.Update(ctx, instance); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	// fetch through the artifact cache, if there is one
+	instance.Status.CacheURL = ""
+	if instance.Spec.Cache != "" {
+		url, err := ArtifactCacheURL(r.Client, instance.Namespace, instance.Spec.Cache)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if url == "" {
+			log.Info("artifact cache not ready (yet)", "cache", instance.Spec.Cache)
+			instance.Status.Ready, instance.Status.State = false, v1alpha1.StatePending
+			if err := r.Update(ctx, instance); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{RequeueAfter: QueueRetryAfter}, nil
+		}
+		instance.Status.CacheURL = url
+	}
+
+	directors, err := TargetDirectors(r.Client, r.Scheme, instance.Namespace, instance.Spec.Director, instance.Spec.ClusterDirector, instance.Spec.DirectorTargets)
 	if err != nil {
-		if errors.IsNotFound(err) {
-			// director was there once, but is gone now
-			return ctrl.Result{}, nil
+		return ctrl.Result{}, err
+	}
+	if len(directors) == 0 {
+		log.Info("no directors to upload stemcell to (yet)")
+		instance.Status.Ready, instance.Status.State = false, v1alpha1.StatePending
+		return ctrl.Result{}, r.Update(ctx, instance)
+	}
+
+	// upload the stemcell to each director (the Jobs run side by side)
+	result := ctrl.Result{}
+	targeted := []v1alpha1.StemcellDirectorStatus{}
+	instance.Status.CurrentTask = nil
+	for _, director := range directors {
+		status := instance.DirectorStatus(director)
+		requeue, err := r.upload(instance, director, status)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if requeue > 0 && (result.RequeueAfter == 0 || requeue < result.RequeueAfter) {
+			result.RequeueAfter = requeue
+		}
+		if status.CurrentTask != nil && instance.Status.CurrentTask == nil {
+			instance.Status.CurrentTask = status.CurrentTask
 		}
+		targeted = append(targeted, *status)
+	}
+
+	// (directors we have already uploaded to, but no longer target,
+	// are only forgotten about once the stemcell is deleted from them)
+	all := targeted
+	for _, s := range instance.Status.Directors {
+		found := false
+		for _, t := range targeted {
+			if s.DirectorStatus.Director == t.DirectorStatus.Director && s.ClusterDirector == t.ClusterDirector {
+				found = true
+				break
+			}
+		}
+		if !found && s.Uploaded != nil {
+			all = append(all, s)
+		}
+	}
+	instance.Status.Directors = all
+	drift(instance, targeted)
+
+	statuses := []v1alpha1.DirectorStatus{}
+	for _, s := range targeted {
+		statuses = append(statuses, s.DirectorStatus)
+	}
+	instance.Status.Ready, instance.Status.State = v1alpha1.Aggregate(statuses)
+	if err := r.Update(ctx, instance); err != nil {
 		return ctrl.Result{}, err
 	}
+	return result, nil
+}
+
+// upload makes sure the stemcell gets uploaded to one director, keeping
+// track of how that is going in status.  It returns how soon to check
+// back in, if it needs to.
+func (r *BOSHStemcellReconciler) upload(instance *v1alpha1.BOSHStemcell, director v1alpha1.Director, status *v1alpha1.StemcellDirectorStatus) (time.Duration, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("boshstemcell", types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}, "director", director.GetName())
+
+	// each revision of the stemcell gets its own upload Job; an upload
+	// of the previous revision that is still running is left to finish
+	// before the new one starts.
+	revision := instance.Revision()
+	if previous := status.Revision; previous != revision {
+		superseded := &batchv1.Job{}
+		err := r.Client.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: instance.RevisionJobName(director, previous)}, superseded)
+		if err == nil && !Finished(superseded) {
+			log.Info("waiting for upload of previous revision to finish", "job", superseded.Name, "revision", previous)
+			status.Ready, status.State = false, v1alpha1.StateQueued
+			return TaskPollInterval, nil
+
+		} else if err == nil && superseded.DeletionTimestamp.IsZero() {
+			log.Info("cleaning up upload job for previous revision", "job", superseded.Name, "revision", previous)
+			background := metav1.DeletePropagationBackground
+			if err := r.Client.Delete(ctx, superseded, &client.DeleteOptions{PropagationPolicy: &background}); err != nil && !errors.IsNotFound(err) {
+				return 0, err
+			}
+
+		} else if err != nil && !errors.IsNotFound(err) {
+			return 0, err
+		}
+	}
 
 	job := &batchv1.Job{}
-	err = r.Client.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: instance.JobName(director)}, job)
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: instance.JobName(director)}, job)
 	if err == nil {
+		if !job.DeletionTimestamp.IsZero() {
+			// on its way out, to make way for a re-upload
+			return QueueRetryAfter, nil
+		}
+		if instance.Spec.Cancel && (!Finished(job) || status.State == v1alpha1.StateCancelling) {
+			log.Info("cancelling job", "job", job.Name)
+			gone, err := CancelJob(r.Client, instance.Namespace, director, job)
+			if err != nil {
+				return 0, err
+			}
+			if task, err := TrackTask(r.Client, instance.Namespace, director, job); err == nil && task != nil {
+				status.CurrentTask = task
+			}
+			status.Ready, status.State = false, v1alpha1.StateCancelling
+			if gone {
+				status.State = v1alpha1.StateCancelled
+				return 0, nil
+			}
+			return TaskPollInterval, nil
+		}
+
 		// job exists; we may have gotten a reconcile request based on our watch(es)
-		instance.Status.Ready, instance.Status.State = v1alpha1.DetermineReadiness(job)
-		if err := r.Update(ctx, instance); err != nil {
-			return ctrl.Result{}, err
+		status.Ready, status.State = v1alpha1.DetermineReadiness(job)
+		if task, err := TrackTask(r.Client, instance.Namespace, director, job); err != nil {
+			log.Info("unable to track director task", "error", err)
+		} else {
+			status.CurrentTask = task
+		}
+		if !Finished(job) {
+			// keep an eye on the task while it runs
+			return TaskPollInterval, nil
+		}
+
+		// remember what we uploaded, so that we can delete it later
+		if status.State == v1alpha1.StateResolved && status.Uploaded == nil {
+			if uploaded := r.uploaded(instance, director, job); uploaded != nil {
+				log.Info("stemcell uploaded", "name", uploaded.Name, "version", uploaded.Version)
+				uploaded.Digest = job.Annotations[v1alpha1.DigestAnnotation]
+				if uploaded.Digest == "" {
+					// the director fetched (and checked) it itself
+					uploaded.Digest = instance.Digest()
+				}
+				status.Uploaded = uploaded
+			}
 		}
 
+		// make sure it is still there; directors get rebuilt, and
+		// people delete stemcells by hand
+		if status.State == v1alpha1.StateResolved && status.Uploaded != nil {
+			return r.verify(instance, director, job, status)
+		}
+		return 0, nil
+
 	} else if !errors.IsNotFound(err) {
-		return ctrl.Result{}, err
+		return 0, err
+	}
 
-	} else {
-		instance.Status.Ready, instance.Status.State = v1alpha1.DetermineReadiness(nil)
-		if err := r.Update(ctx, instance); err != nil {
-			return ctrl.Result{}, err
+	// don't start anything new until un-cancelled
+	if instance.Spec.Cancel {
+		log.Info("cancelled; not starting a new stemcell upload")
+		status.Ready, status.State = false, v1alpha1.StateCancelled
+		return 0, nil
+	}
+	if instance.FromIndex() && instance.Status.Resolved == nil {
+		return 0, nil
+	}
+
+	// wait our turn if the director is already busy
+	if queued, err := Queued(r.Client, director, v1alpha1.OperationStemcell); err != nil {
+		return 0, err
+	} else if queued {
+		log.Info("director is busy; queueing stemcell upload")
+		status.Ready, status.State = false, v1alpha1.StateQueued
+		return QueueRetryAfter, nil
+	}
+
+	// create the Job resource, in all of its glory
+	log.Info("creating stemcell upload job", "job", instance.JobName(director))
+	status.Ready, status.State = v1alpha1.DetermineReadiness(nil)
+	job = instance.Job(director)
+	if err := controllerutil.SetControllerReference(instance, job, r.Scheme); err != nil {
+		return 0, err
+	}
+	if admitted, err := CreateQueued(r.Client, director, v1alpha1.OperationStemcell, job); err != nil {
+		return 0, err
+	} else if !admitted {
+		log.Info("director got busy; queueing stemcell upload")
+		status.Ready, status.State = false, v1alpha1.StateQueued
+		return QueueRetryAfter, nil
+	}
+
+	// whatever was uploaded before is not what this Job uploads
+	// (though it stays on the director)
+	if status.Revision != revision {
+		status.Uploaded, status.VerifiedAt, status.Missing = nil, nil, false
+	}
+	status.Revision = revision
+
+	// job created.
+	return 0, nil
+}
+
+// verify checks (every so often) that the uploaded stemcell is still on
+// the director.  If it isn't, verify gets rid of the upload Job, so that
+// the stemcell gets uploaded again.
+func (r *BOSHStemcellReconciler) verify(instance *v1alpha1.BOSHStemcell, director v1alpha1.Director, job *batchv1.Job, status *v1alpha1.StemcellDirectorStatus) (time.Duration, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("boshstemcell", types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}, "director", director.GetName())
+
+	if status.VerifiedAt != nil {
+		if since := time.Since(status.VerifiedAt.Time); since < StemcellVerifyInterval {
+			return StemcellVerifyInterval - since, nil
 		}
+	}
 
-		// create the Job resource, in all of its glory
-		job := instance.Job(director)
-		if err := controllerutil.SetControllerReference(instance, job, r.Scheme); err != nil {
-			return ctrl.Result{}, err
+	// (inUse returns nil if the director doesn't have the stemcell)
+	uploaded := status.Uploaded
+	deployments, err := r.inUse(instance, director, uploaded)
+	if err != nil {
+		log.Info("unable to verify stemcell on director", "error", err)
+		return StemcellVerifyInterval, nil
+	}
+	if deployments != nil {
+		now := metav1.Now()
+		status.VerifiedAt = &now
+		status.Missing = false
+		return StemcellVerifyInterval, nil
+	}
+
+	log.Info("stemcell has gone missing from the director; uploading it again", "name", uploaded.Name, "version", uploaded.Version)
+	r.Recorder.Eventf(instance, corev1.EventTypeWarning, "StemcellMissing",
+		"stemcell %s/%s is no longer on director %s; uploading it again", uploaded.Name, uploaded.Version, director.GetName())
+
+	background := metav1.DeletePropagationBackground
+	if err := r.Client.Delete(ctx, job, &client.DeleteOptions{PropagationPolicy: &background}); err != nil && !errors.IsNotFound(err) {
+		return 0, err
+	}
+	status.Missing = true
+	status.Uploaded, status.VerifiedAt, status.CurrentTask = nil, nil, nil
+	status.Ready, status.State = false, v1alpha1.StatePending
+	return QueueRetryAfter, nil
+}
+
+// drift sums up which directors the stemcell went missing from, as the
+// Drifted condition.
+func drift(instance *v1alpha1.BOSHStemcell, statuses []v1alpha1.StemcellDirectorStatus) {
+	missing := []string{}
+	for _, s := range statuses {
+		if s.Missing {
+			missing = append(missing, s.Name())
 		}
-		if err = r.Client.Create(ctx, job); err != nil {
+	}
+	if len(missing) > 0 {
+		instance.Status.Conditions.Set(v1alpha1.ConditionDrifted, corev1.ConditionTrue, "StemcellMissing",
+			fmt.Sprintf("stemcell went missing from director(s) %s; uploading it again", strings.Join(missing, ", ")))
+	} else if instance.Status.Conditions.IsTrue(v1alpha1.ConditionDrifted) {
+		instance.Status.Conditions.Set(v1alpha1.ConditionDrifted, corev1.ConditionFalse, "Verified",
+			"stemcell is back on all of its directors")
+	}
+}
+
+// uploaded works out which stemcell the upload Job put on the director;
+// either the spec says, or the upload task's result does.
+func (r *BOSHStemcellReconciler) uploaded(instance *v1alpha1.BOSHStemcell, director v1alpha1.Director, job *batchv1.Job) *v1alpha1.UploadedStemcell {
+	if r := instance.Status.Resolved; instance.FromIndex() && r != nil {
+		return &v1alpha1.UploadedStemcell{Name: r.Name, Version: r.Version}
+	}
+	if instance.Spec.Name != "" && instance.Spec.Version != "" {
+		return &v1alpha1.UploadedStemcell{Name: instance.Spec.Name, Version: instance.Spec.Version}
+	}
+
+	id, err := strconv.Atoi(job.Annotations[v1alpha1.TaskAnnotation])
+	if err != nil {
+		return nil
+	}
+	d, err := DirectorClient(r.Client, instance.Namespace, director.SecretsName())
+	if err != nil {
+		return nil
+	}
+	task, err := d.Task(id)
+	if err != nil {
+		return nil
+	}
+
+	// upload tasks result in /stemcells/<name>/<version>
+	parts := strings.Split(strings.TrimSpace(task.Result), "/")
+	if len(parts) != 4 || parts[1] != "stemcells" {
+		return nil
+	}
+	return &v1alpha1.UploadedStemcell{Name: parts[2], Version: parts[3]}
+}
+
+// finalize deletes the stemcell from each director it was uploaded to, if
+// its deletion policy says to (and no deployment is still using it
+// there), and lets go of the BOSHStemcell once that is done.
+func (r *BOSHStemcellReconciler) finalize(instance *v1alpha1.BOSHStemcell) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("boshstemcell", types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name})
+
+	if !instance.Spec.DeletionPolicy.Deletes() {
+		log.Info("leaving stemcell on the director(s)", "policy", instance.Spec.DeletionPolicy)
+		return ctrl.Result{}, r.release(instance)
+	}
+
+	// directors drop off the list as we finish with them
+	result := ctrl.Result{}
+	remaining := []v1alpha1.StemcellDirectorStatus{}
+	for _, status := range instance.Status.Directors {
+		status := status
+		done, requeue, err := r.delete(instance, &status)
+		if err != nil {
 			return ctrl.Result{}, err
 		}
+		if !done {
+			remaining = append(remaining, status)
+		}
+		if requeue > 0 && (result.RequeueAfter == 0 || requeue < result.RequeueAfter) {
+			result.RequeueAfter = requeue
+		}
+	}
+	instance.Status.Directors = remaining
+
+	if len(remaining) == 0 {
+		return ctrl.Result{}, r.release(instance)
+	}
+	return result, r.Update(ctx, instance)
+}
+
+// delete removes the stemcell from one director.  It returns true once
+// there is nothing more to be done there.
+func (r *BOSHStemcellReconciler) delete(instance *v1alpha1.BOSHStemcell, status *v1alpha1.StemcellDirectorStatus) (bool, time.Duration, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("boshstemcell", types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}, "director", status.DirectorStatus.Director, "cluster-director", status.ClusterDirector)
+
+	director, err := LookupDirector(r.Client, r.Scheme, instance.Namespace, status.DirectorStatus.Director, status.ClusterDirector)
+	if err != nil {
+		return false, 0, err
+	}
+	if director == nil {
+		log.Info("director not found; leaving stemcell in place")
+		return true, 0, nil
+	}
+
+	uploaded := status.Uploaded
+	if uploaded == nil {
+		r.Recorder.Eventf(instance, corev1.EventTypeWarning, "StemcellNotDeleted",
+			"not deleting stemcell from director %s: unable to tell which stemcell was uploaded", director.GetName())
+		return true, 0, nil
+	}
+
+	job := &batchv1.Job{}
+	err = r.Client.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: instance.DeleteJobName(director)}, job)
+	if err == nil {
+		status.Ready, status.State = v1alpha1.DetermineReadiness(job)
+		if !Finished(job) {
+			return false, 0, nil
+		}
+		if status.State == v1alpha1.StateFailed {
+			// someone may have started using it (or deleted it) in the
+			// meantime
+			if inUse, err := r.inUse(instance, director, uploaded); err == nil && inUse == nil {
+				log.Info("stemcell is no longer on the director", "name", uploaded.Name, "version", uploaded.Version)
+				return true, 0, nil
+			} else if err == nil && len(inUse) > 0 {
+				r.skip(instance, director, uploaded, inUse)
+				return true, 0, nil
+			}
+
+			log.Info("delete-stemcell failed; retrying it", "job", job.Name, "retries", status.Retries)
+			r.Recorder.Eventf(instance, corev1.EventTypeWarning, "StemcellDeleteFailed",
+				"unable to delete stemcell %s/%s from director %s (see job %s); retrying",
+				uploaded.Name, uploaded.Version, director.GetName(), job.Name)
+			retry, err := RetryFailed(r.Client, job, &status.DirectorStatus)
+			return false, retry, err
+		}
+		return true, 0, nil
+
+	} else if !errors.IsNotFound(err) {
+		return false, 0, err
+	}
+
+	// BOSH won't delete stemcells that deployments are using, and
+	// neither will we.
+	inUse, err := r.inUse(instance, director, uploaded)
+	if err != nil {
+		return false, 0, err
+	}
+	if inUse == nil {
+		log.Info("stemcell is no longer on the director", "name", uploaded.Name, "version", uploaded.Version)
+		return true, 0, nil
+	}
+	if len(inUse) > 0 {
+		r.skip(instance, director, uploaded, inUse)
+		return true, 0, nil
+	}
+
+	if queued, err := Queued(r.Client, director, v1alpha1.OperationStemcell); err != nil {
+		return false, 0, err
+	} else if queued {
+		log.Info("director is busy; queueing stemcell removal")
+		status.Ready, status.State = false, v1alpha1.StateQueued
+		return false, QueueRetryAfter, nil
+	}
+
+	log.Info("creating delete-stemcell job", "job", instance.DeleteJobName(director))
+	job = instance.DeleteJob(director, uploaded)
+	if err := controllerutil.SetControllerReference(instance, job, r.Scheme); err != nil {
+		return false, 0, err
+	}
+	if admitted, err := CreateQueued(r.Client, director, v1alpha1.OperationStemcell, job); err != nil {
+		return false, 0, err
+	} else if !admitted {
+		log.Info("director got busy; queueing stemcell removal")
+		status.Ready, status.State = false, v1alpha1.StateQueued
+		return false, QueueRetryAfter, nil
+	}
+	return false, 0, nil
+}
 
-		// job created.
+// inUse returns the names of the deployments that are using the uploaded
+// stemcell, according to the director.  If the stemcell isn't on the
+// director at all, inUse returns nil (rather than an empty list).
+func (r *BOSHStemcellReconciler) inUse(instance *v1alpha1.BOSHStemcell, director v1alpha1.Director, uploaded *v1alpha1.UploadedStemcell) ([]string, error) {
+	d, err := DirectorClient(r.Client, instance.Namespace, director.SecretsName())
+	if err != nil {
+		return nil, err
 	}
+	stemcells, err := d.Stemcells()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range stemcells {
+		if s.Name == uploaded.Name && s.Version == uploaded.Version {
+			deployments := []string{}
+			for _, d := range s.Deployments {
+				deployments = append(deployments, d.Name)
+			}
+			return deployments, nil
+		}
+	}
+	return nil, nil
+}
+
+// skip reports that the stemcell is being left on a director, because
+// deployments are still using it.
+func (r *BOSHStemcellReconciler) skip(instance *v1alpha1.BOSHStemcell, director v1alpha1.Director, uploaded *v1alpha1.UploadedStemcell, deployments []string) {
+	r.Log.Info("stemcell is still in use; leaving it on the director",
+		"boshstemcell", types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}, "director", director.GetName(), "deployments", deployments)
+	r.Recorder.Eventf(instance, corev1.EventTypeWarning, "StemcellInUse",
+		"not deleting stemcell %s/%s from director %s: still in use by deployment(s) %s",
+		uploaded.Name, uploaded.Version, director.GetName(), strings.Join(deployments, ", "))
+}
 
-	return ctrl.Result{}, nil
+// release removes our finalizer, so that Kubernetes can finish deleting
+// the BOSHStemcell (and garbage-collect whatever it still owns).
+func (r *BOSHStemcellReconciler) release(instance *v1alpha1.BOSHStemcell) error {
+	controllerutil.RemoveFinalizer(instance, StemcellFinalizer)
+	return r.Update(context.Background(), instance)
 }
 
 func (r *BOSHStemcellReconciler) SetupWithManager(mgr ctrl.Manager) error {
@@ -0,0 +1,265 @@
+/*
+Gluon - BOSH / CF Orchestration via Kuberenetes API(s)
+
+Copyright (c) 2020 James Hunt
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to
+deal in the Software without restriction, including without limitation the
+rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+sell copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software..
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+IN THE SOFTWARE.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	v1alpha1 "github.com/starkandwayne/gluon-controller/api/v1alpha1"
+	"github.com/starkandwayne/gluon-controller/boshio"
+)
+
+// BOSHStemcellPolicyReconciler reconciles a BOSHStemcellPolicy object
+type BOSHStemcellPolicyReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=gluon.starkandwayne.com,resources=boshstemcellpolicies,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=gluon.starkandwayne.com,resources=boshstemcellpolicies/status,verbs=get;update;patch
+
+func (r *BOSHStemcellPolicyReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("boshstemcellpolicy", req.NamespacedName)
+
+	// fetch the BOSHStemcellPolicy instance
+	instance := &v1alpha1.BOSHStemcellPolicy{}
+	err := r.Client.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// that's ok, maybe someone got cold feet and deleted it.
+			return ctrl.Result{}, nil
+		}
+		// something else went wrong...
+		return ctrl.Result{}, err
+	}
+
+	// check for newer stemcells, every so often
+	if instance.Status.CheckedAt == nil || time.Since(instance.Status.CheckedAt.Time) >= instance.Interval() {
+		index := boshio.New(StemcellIndex)
+		log.Info("checking for newer stemcells", "index", index.URL, "os", instance.Spec.OS, "version", instance.Spec.Version, "iaas", instance.Spec.IaaS)
+		now := metav1.Now()
+		instance.Status.CheckedAt = &now
+		stemcell, err := index.Resolve(boshio.Query{
+			OS:      instance.Spec.OS,
+			Version: instance.Spec.Version,
+			IaaS:    instance.Spec.IaaS,
+			Light:   instance.Spec.Light,
+		})
+		if err != nil {
+			log.Info("unable to check for newer stemcells", "error", err)
+			instance.Status.Error = err.Error()
+			instance.Status.Ready, instance.Status.State = false, v1alpha1.StateFailed
+			if err := r.Update(ctx, instance); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{RequeueAfter: instance.Interval()}, nil
+		}
+		if stemcell.Version != instance.Status.Latest {
+			log.Info("found a newer stemcell", "name", stemcell.Name, "version", stemcell.Version)
+			instance.Status.LatestSince = &now
+		}
+		instance.Status.Error = ""
+		instance.Status.Latest = stemcell.Version
+	}
+	next := instance.Interval() - time.Since(instance.Status.CheckedAt.Time)
+
+	if instance.Status.Latest == "" {
+		return ctrl.Result{RequeueAfter: next}, r.Update(ctx, instance)
+	}
+
+	// upload the latest stemcell
+	latest := &v1alpha1.BOSHStemcell{}
+	err = r.Client.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: instance.StemcellName(instance.Status.Latest)}, latest)
+	if errors.IsNotFound(err) {
+		log.Info("creating stemcell", "boshstemcell", instance.StemcellName(instance.Status.Latest))
+		latest = instance.Stemcell(instance.Status.Latest)
+		if err := controllerutil.SetControllerReference(instance, latest, r.Scheme); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.Client.Create(ctx, latest); err != nil {
+			return ctrl.Result{}, err
+		}
+	} else if err != nil {
+		return ctrl.Result{}, err
+	}
+	instance.Status.Ready, instance.Status.State = latest.Status.Ready, latest.Status.State
+
+	// keep the newest few, and anything still in use
+	stemcells := &v1alpha1.BOSHStemcellList{}
+	if err := r.Client.List(ctx, stemcells, client.InNamespace(instance.Namespace), client.MatchingLabels{v1alpha1.StemcellPolicyLabel: instance.Name}); err != nil {
+		return ctrl.Result{}, err
+	}
+	sort.Slice(stemcells.Items, func(i, j int) bool {
+		return boshio.Compare(stemcells.Items[i].Spec.Version, stemcells.Items[j].Spec.Version) > 0
+	})
+	instance.Status.Versions = nil
+	for i := range stemcells.Items {
+		stemcell := &stemcells.Items[i]
+		if !stemcell.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if len(instance.Status.Versions) < instance.Keep() || !latest.Status.Ready {
+			// (don't clean up until the new one is in place)
+			instance.Status.Versions = append(instance.Status.Versions, stemcell.Spec.Version)
+			continue
+		}
+
+		inUse, err := r.inUse(instance, stemcell)
+		if err != nil {
+			log.Info("unable to tell if old stemcell is in use; keeping it", "boshstemcell", stemcell.Name, "error", err)
+			inUse = true
+		}
+		if inUse {
+			instance.Status.Versions = append(instance.Status.Versions, stemcell.Spec.Version)
+			continue
+		}
+		log.Info("cleaning up old stemcell", "boshstemcell", stemcell.Name, "version", stemcell.Spec.Version)
+		if err := r.Client.Delete(ctx, stemcell); err != nil && !errors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// the first stemcell the policy sees is just where things stand;
+	// only the ones that come after it are rolled out.
+	if instance.Status.RolledOut == "" && latest.Status.Ready {
+		log.Info("not redeploying for the first stemcell", "version", instance.Status.Latest)
+		instance.Status.RolledOut = instance.Status.Latest
+	}
+
+	// redeploy whoever asked to be, once the new stemcell is there
+	if instance.Spec.Redeploy && latest.Status.Ready && instance.Status.RolledOut != instance.Status.Latest {
+		if done, err := r.redeploy(instance); err != nil {
+			return ctrl.Result{}, err
+		} else if !done {
+			if err := r.Update(ctx, instance); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{RequeueAfter: QueueRetryAfter}, nil
+		}
+		instance.Status.RolledOut = instance.Status.Latest
+	}
+
+	if err := r.Update(ctx, instance); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: next}, nil
+}
+
+// inUse asks the directors whether any deployment is using the stemcell
+// that a BOSHStemcell uploaded to them.
+func (r *BOSHStemcellPolicyReconciler) inUse(instance *v1alpha1.BOSHStemcellPolicy, stemcell *v1alpha1.BOSHStemcell) (bool, error) {
+	for _, status := range stemcell.Status.Directors {
+		uploaded := status.Uploaded
+		if uploaded == nil {
+			// never made it to this director
+			continue
+		}
+
+		director, err := LookupDirector(r.Client, r.Scheme, instance.Namespace, status.Director, status.ClusterDirector)
+		if err != nil {
+			return false, err
+		}
+		if director == nil {
+			return false, fmt.Errorf("director not found")
+		}
+		d, err := DirectorClient(r.Client, instance.Namespace, director.SecretsName())
+		if err != nil {
+			return false, err
+		}
+		stemcells, err := d.Stemcells()
+		if err != nil {
+			return false, err
+		}
+		for _, s := range stemcells {
+			if s.Name == uploaded.Name && s.Version == uploaded.Version && len(s.Deployments) > 0 {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// redeploy re-runs the deploy Jobs of the BOSHDeployments that opted in
+// to this policy.  Deployments that are in the middle of a deploy are
+// left to finish it first; redeploy returns false if there were any.
+func (r *BOSHStemcellPolicyReconciler) redeploy(instance *v1alpha1.BOSHStemcellPolicy) (bool, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("boshstemcellpolicy", types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name})
+
+	deployments := &v1alpha1.BOSHDeploymentList{}
+	if err := r.Client.List(ctx, deployments, client.InNamespace(instance.Namespace), client.MatchingLabels{v1alpha1.StemcellPolicyLabel: instance.Name}); err != nil {
+		return false, err
+	}
+
+	done := true
+	background := metav1.DeletePropagationBackground
+	for _, bd := range deployments.Items {
+		job := &batchv1.Job{}
+		err := r.Client.Get(ctx, types.NamespacedName{Namespace: bd.Namespace, Name: bd.JobName("deploy")}, job)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return false, err
+		}
+		if instance.Status.LatestSince != nil && job.CreationTimestamp.After(instance.Status.LatestSince.Time) {
+			// already redeployed since the new stemcell showed up
+			continue
+		}
+		if !Finished(job) {
+			log.Info("deployment is busy; holding off on redeploying it", "boshdeployment", bd.Name)
+			done = false
+			continue
+		}
+
+		log.Info("redeploying for new stemcell", "boshdeployment", bd.Name, "version", instance.Status.Latest)
+		if err := r.Client.Delete(ctx, job, &client.DeleteOptions{PropagationPolicy: &background}); err != nil && !errors.IsNotFound(err) {
+			return false, err
+		}
+	}
+	return done, nil
+}
+
+func (r *BOSHStemcellPolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.BOSHStemcellPolicy{}).
+		Owns(&v1alpha1.BOSHStemcell{}).
+		Complete(r)
+}
@@ -0,0 +1,170 @@
+/*
+Gluon - BOSH / CF Orchestration via Kuberenetes API(s)
+
+Copyright (c) 2020 James Hunt
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to
+deal in the Software without restriction, including without limitation the
+rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+sell copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software..
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+IN THE SOFTWARE.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	v1alpha1 "github.com/starkandwayne/gluon-controller/api/v1alpha1"
+)
+
+// ClusterBOSHDirectorReconciler reconciles a ClusterBOSHDirector object
+type ClusterBOSHDirectorReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=gluon.starkandwayne.com,resources=clusterboshdirectors,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=gluon.starkandwayne.com,resources=clusterboshdirectors/status,verbs=get;update;patch
+
+func (r *ClusterBOSHDirectorReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("clusterboshdirector", req.Name)
+
+	// fetch the ClusterBOSHDirector instance
+	instance := &v1alpha1.ClusterBOSHDirector{}
+	err := r.Client.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// that's ok, maybe someone got cold feet and deleted it.
+			return ctrl.Result{}, nil
+		}
+		// something else went wrong...
+		return ctrl.Result{}, err
+	}
+
+	// make sure the credentials are there for us to hand out
+	log.Info("checking for director credentials", "namespace", v1alpha1.GluonNamespace, "secret", instance.Spec.Secret)
+	secret := &corev1.Secret{}
+	err = r.Client.Get(ctx, types.NamespacedName{Namespace: v1alpha1.GluonNamespace, Name: instance.Spec.Secret}, secret)
+	if err != nil && !errors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+
+	if err != nil {
+		log.Info("director credentials not found", "namespace", v1alpha1.GluonNamespace, "secret", instance.Spec.Secret)
+		instance.Status.Ready, instance.Status.State = false, v1alpha1.StatePending
+	} else {
+		instance.Status.Ready, instance.Status.State = true, v1alpha1.StateResolved
+	}
+	if err := r.Update(ctx, instance); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// generate the ops file for pointing the health monitor at us
+	if instance.Spec.ForwardAlerts {
+		log.Info("checking alert forwarding configuration", "configmap", instance.AlertsName())
+		if err := EnsureAlertForwarding(r.Client, r.Scheme, instance, v1alpha1.GluonNamespace, instance.AlertsName(), true); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// refresh (or revoke) any copies we have already handed out
+	mirrors := &corev1.SecretList{}
+	err = r.Client.List(ctx, mirrors, client.MatchingLabels{v1alpha1.ClusterDirectorLabel: instance.Name})
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	for _, mirror := range mirrors.Items {
+		ok, err := ClusterDirectorAllows(r.Client, instance, mirror.Namespace)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		if !ok || !instance.Status.Ready {
+			log.Info("revoking director credentials", "namespace", mirror.Namespace, "secret", mirror.Name)
+			if err := r.Client.Delete(ctx, &mirror); err != nil && !errors.IsNotFound(err) {
+				return ctrl.Result{}, err
+			}
+			continue
+		}
+
+		log.Info("refreshing director credentials", "namespace", mirror.Namespace, "secret", mirror.Name)
+		if err := MirrorClusterDirectorSecret(r.Client, r.Scheme, instance, mirror.Namespace); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// keep tabs on what is actually on the director
+	if instance.Status.Ready {
+		if due := InventoryDue(instance.Status.Inventory); due > 0 {
+			return ctrl.Result{RequeueAfter: due}, nil
+		}
+
+		log.Info("taking director inventory")
+		instance.Status.Inventory = Inventory(r.Client, v1alpha1.GluonNamespace, instance.Spec.Secret)
+		if err := r.Update(ctx, instance); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: InventoryInterval}, nil
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (r *ClusterBOSHDirectorReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.ClusterBOSHDirector{}).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(r.directorsUsingSecret),
+		}).
+		Complete(r)
+}
+
+// directorsUsingSecret maps changes to Secrets in the Gluon namespace to
+// the cluster directors that get their credentials from them.
+func (r *ClusterBOSHDirectorReconciler) directorsUsingSecret(o handler.MapObject) []ctrl.Request {
+	if o.Meta.GetNamespace() != v1alpha1.GluonNamespace {
+		return nil
+	}
+
+	directors := &v1alpha1.ClusterBOSHDirectorList{}
+	if err := r.Client.List(context.Background(), directors); err != nil {
+		r.Log.Error(err, "unable to list cluster directors")
+		return nil
+	}
+
+	var requests []ctrl.Request
+	for _, director := range directors.Items {
+		if director.Spec.Secret == o.Meta.GetName() {
+			requests = append(requests, ctrl.Request{
+				NamespacedName: types.NamespacedName{Name: director.Name},
+			})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,208 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	v1alpha1 "github.com/starkandwayne/gluon-controller/api/v1alpha1"
+)
+
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
+
+// LookupDirector finds the director that an object in namespace ns is
+// targeting; either the named BOSHDeployment in that same namespace, or
+// the named ClusterBOSHDirector (if that namespace is allowed to use it).
+//
+// For cluster directors, the director credentials are mirrored into ns,
+// so that Jobs running there can get at them.
+//
+// If the director cannot be found, LookupDirector returns a nil Director
+// and a nil error; callers should treat that as "not yet".
+func LookupDirector(c client.Client, scheme *runtime.Scheme, ns, name, cluster string) (v1alpha1.Director, error) {
+	ctx := context.Background()
+
+	if name != "" && cluster != "" {
+		return nil, fmt.Errorf("both director '%s' and cluster director '%s' specified", name, cluster)
+	}
+
+	if cluster == "" {
+		director := &v1alpha1.BOSHDeployment{}
+		err := c.Get(ctx, types.NamespacedName{Namespace: ns, Name: name}, director)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return director, nil
+	}
+
+	director := &v1alpha1.ClusterBOSHDirector{}
+	err := c.Get(ctx, types.NamespacedName{Name: cluster}, director)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if ok, err := ClusterDirectorAllows(c, director, ns); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, fmt.Errorf("cluster director '%s' is not available to namespace '%s'", cluster, ns)
+	}
+
+	if err := MirrorClusterDirectorSecret(c, scheme, director, ns); err != nil {
+		return nil, err
+	}
+	return director, nil
+}
+
+// ClusterDirectorAllows checks the namespace selector of a cluster director
+// against the labels of namespace ns.
+func ClusterDirectorAllows(c client.Client, director *v1alpha1.ClusterBOSHDirector, ns string) (bool, error) {
+	selector, err := metav1.LabelSelectorAsSelector(director.Spec.NamespaceSelector)
+	if err != nil {
+		return false, err
+	}
+
+	namespace := &corev1.Namespace{}
+	err = c.Get(context.Background(), types.NamespacedName{Name: ns}, namespace)
+	if err != nil {
+		return false, err
+	}
+
+	return selector.Matches(labels.Set(namespace.Labels)), nil
+}
+
+// MirrorClusterDirectorSecret copies the credentials of a cluster director
+// out of the Gluon namespace and into namespace ns, creating or updating
+// the copy as needed.
+func MirrorClusterDirectorSecret(c client.Client, scheme *runtime.Scheme, director *v1alpha1.ClusterBOSHDirector, ns string) error {
+	ctx := context.Background()
+
+	source := &corev1.Secret{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: v1alpha1.GluonNamespace, Name: director.Spec.Secret}, source)
+	if err != nil {
+		return err
+	}
+
+	mirror := &corev1.Secret{}
+	err = c.Get(ctx, types.NamespacedName{Namespace: ns, Name: director.SecretsName()}, mirror)
+	if err == nil {
+		mirror.Data = source.Data
+		return c.Update(ctx, mirror)
+
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+
+	mirror = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: ns,
+			Name:      director.SecretsName(),
+			Labels: map[string]string{
+				v1alpha1.ClusterDirectorLabel: director.Name,
+			},
+		},
+		Data: source.Data,
+	}
+	if err := controllerutil.SetControllerReference(director, mirror, scheme); err != nil {
+		return err
+	}
+	return c.Create(ctx, mirror)
+}
+
+// TargetDirectors finds all of the directors that an object in namespace
+// ns is bound for: the one named by director / cluster (if any), plus
+// those picked out by targets.  Directors that cannot be found (yet) are
+// skipped.
+func TargetDirectors(c client.Client, scheme *runtime.Scheme, ns, director, cluster string, targets v1alpha1.DirectorTargets) ([]v1alpha1.Director, error) {
+	ctx := context.Background()
+
+	directors := []v1alpha1.Director{}
+	seen := make(map[string]bool)
+	add := func(name, cluster string) error {
+		key := name + "/" + cluster
+		if seen[key] {
+			return nil
+		}
+		seen[key] = true
+
+		director, err := LookupDirector(c, scheme, ns, name, cluster)
+		if err != nil || director == nil {
+			return err
+		}
+		directors = append(directors, director)
+		return nil
+	}
+
+	if director != "" || cluster != "" {
+		if err := add(director, cluster); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range targets.Directors {
+		if err := add(name, ""); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range targets.ClusterDirectors {
+		if err := add("", name); err != nil {
+			return nil, err
+		}
+	}
+
+	if targets.DirectorSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(targets.DirectorSelector)
+		if err != nil {
+			return nil, err
+		}
+		l := &v1alpha1.BOSHDeploymentList{}
+		if err := c.List(ctx, l, client.InNamespace(ns), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+			return nil, err
+		}
+		for _, bd := range l.Items {
+			if bd.ViaDirector() {
+				// not a director
+				continue
+			}
+			if err := add(bd.Name, ""); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if targets.ClusterDirectorSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(targets.ClusterDirectorSelector)
+		if err != nil {
+			return nil, err
+		}
+		l := &v1alpha1.ClusterBOSHDirectorList{}
+		if err := c.List(ctx, l, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+			return nil, err
+		}
+		for _, cbd := range l.Items {
+			if ok, err := ClusterDirectorAllows(c, &cbd, ns); err != nil {
+				return nil, err
+			} else if !ok {
+				continue
+			}
+			if err := add("", cbd.Name); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return directors, nil
+}
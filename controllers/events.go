@@ -0,0 +1,424 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/starkandwayne/gluon-controller/api/v1alpha1"
+	"github.com/starkandwayne/gluon-controller/bosh"
+)
+
+// EventsInterval is how often Gluon checks directors for new events.
+var EventsInterval = time.Minute
+
+// how far back (in pages of director events) to go looking for the cursor
+const eventPages = 10
+
+// EventWatcher mirrors the events of BOSH directors that have
+// spec.watchEvents set as Kubernetes Events, on the BOSHDeployments,
+// BOSHStemcells, BOSHReleases and BOSHConfigs they are about.  It runs
+// alongside the controllers, in the manager.
+type EventWatcher struct {
+	client.Client
+	Log      logr.Logger
+	Recorder record.EventRecorder
+}
+
+// Start polls the directors every EventsInterval, until stop is closed.
+func (w *EventWatcher) Start(stop <-chan struct{}) error {
+	t := time.NewTicker(EventsInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-t.C:
+			w.poll()
+		}
+	}
+}
+
+func (w *EventWatcher) poll() {
+	ctx := context.Background()
+
+	directors := &v1alpha1.BOSHDeploymentList{}
+	if err := w.List(ctx, directors); err != nil {
+		w.Log.Info("unable to list directors", "error", err)
+		return
+	}
+	for i := range directors.Items {
+		bd := &directors.Items[i]
+		if !bd.Spec.WatchEvents || bd.ViaDirector() || bd.Status.State != v1alpha1.StateResolved {
+			continue
+		}
+		name := types.NamespacedName{Namespace: bd.Namespace, Name: bd.Name}
+		status := w.watch(bd, bd.Namespace, bd.SecretsName(), bd.Status.Events)
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			bd := &v1alpha1.BOSHDeployment{}
+			if err := w.Get(ctx, name, bd); err != nil {
+				return err
+			}
+			bd.Status.Events = status
+			return w.Update(ctx, bd)
+		})
+		if err != nil {
+			w.Log.Info("unable to update director events cursor", "boshdeployment", name, "error", err)
+		}
+	}
+
+	clusterDirectors := &v1alpha1.ClusterBOSHDirectorList{}
+	if err := w.List(ctx, clusterDirectors); err != nil {
+		w.Log.Info("unable to list cluster directors", "error", err)
+		return
+	}
+	for i := range clusterDirectors.Items {
+		cbd := &clusterDirectors.Items[i]
+		if !cbd.Spec.WatchEvents || !cbd.Status.Ready {
+			continue
+		}
+		name := types.NamespacedName{Name: cbd.Name}
+		status := w.watch(cbd, v1alpha1.GluonNamespace, cbd.Spec.Secret, cbd.Status.Events)
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			cbd := &v1alpha1.ClusterBOSHDirector{}
+			if err := w.Get(ctx, name, cbd); err != nil {
+				return err
+			}
+			cbd.Status.Events = status
+			return w.Update(ctx, cbd)
+		})
+		if err != nil {
+			w.Log.Info("unable to update director events cursor", "clusterboshdirector", name, "error", err)
+		}
+	}
+}
+
+// watch mirrors the events on a director (whose credentials are in the
+// named Secret) since the last time, and returns where it got to.
+func (w *EventWatcher) watch(director v1alpha1.Director, ns, secret string, last *v1alpha1.EventsStatus) *v1alpha1.EventsStatus {
+	now := metav1.Now()
+	status := &v1alpha1.EventsStatus{CheckedAt: &now}
+	if last != nil {
+		status.Cursor = last.Cursor
+	}
+
+	d, err := DirectorClient(w.Client, ns, secret)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	events, cursor, err := newEvents(d, status.Cursor)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	if len(events) == 0 {
+		status.Cursor = cursor
+		return status
+	}
+
+	ours, err := w.gluonTasks(director)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	subjects, err := w.subjects(director)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+
+	for _, e := range events {
+		obj := subjects.about(e)
+		if obj == nil {
+			// not about anything we manage; record it on the director
+			obj = director.(runtime.Object)
+		}
+
+		eventType, reason := corev1.EventTypeNormal, "DirectorEvent"
+		if e.Task == "" || !ours[e.Task] {
+			eventType, reason = corev1.EventTypeWarning, "OutOfBandChange"
+		}
+		w.Recorder.AnnotatedEventf(obj, map[string]string{
+			v1alpha1.EventIDAnnotation:   e.ID,
+			v1alpha1.EventTimeAnnotation: time.Unix(e.Timestamp, 0).UTC().Format(time.RFC3339),
+		}, eventType, reason, "%s", describeEvent(e))
+	}
+	w.Log.Info("mirrored director events", "director", director.GetName(), "events", len(events), "cursor", cursor)
+
+	status.Cursor = cursor
+	return status
+}
+
+// newEvents returns the director events newer than cursor, oldest first,
+// and the new cursor.  The first time through (without a cursor), it
+// only finds out where the cursor should be, rather than replaying the
+// director's entire history.
+func newEvents(d *bosh.Client, cursor string) ([]bosh.Event, string, error) {
+	page, err := d.Events(bosh.EventFilter{})
+	if err != nil {
+		return nil, cursor, err
+	}
+	if cursor == "" {
+		if len(page) > 0 {
+			return nil, page[0].ID, nil
+		}
+		return nil, "", nil
+	}
+
+	var events []bosh.Event
+	for n := 0; n < eventPages && len(page) > 0; n++ {
+		for _, e := range page {
+			if !newerEvent(e.ID, cursor) {
+				return oldestFirst(events), newestEvent(events, cursor), nil
+			}
+			events = append(events, e)
+		}
+		page, err = d.Events(bosh.EventFilter{BeforeID: page[len(page)-1].ID})
+		if err != nil {
+			return nil, cursor, err
+		}
+	}
+	return oldestFirst(events), newestEvent(events, cursor), nil
+}
+
+func newerEvent(id, than string) bool {
+	a, err1 := strconv.Atoi(id)
+	b, err2 := strconv.Atoi(than)
+	if err1 != nil || err2 != nil {
+		return id != than
+	}
+	return a > b
+}
+
+func newestEvent(events []bosh.Event, cursor string) string {
+	if len(events) == 0 {
+		return cursor
+	}
+	return events[0].ID
+}
+
+func oldestFirst(events []bosh.Event) []bosh.Event {
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+	return events
+}
+
+// describeEvent renders a director event as a one-line message, à la
+// "admin update deployment cf (task 1312)".
+func describeEvent(e bosh.Event) string {
+	msg := fmt.Sprintf("%s %s %s", e.User, e.Action, e.ObjectType)
+	if e.ObjectName != "" {
+		msg += " " + e.ObjectName
+	}
+	if e.Deployment != "" && e.Deployment != e.ObjectName {
+		msg += " in deployment " + e.Deployment
+	}
+	if e.Instance != "" && e.Instance != e.ObjectName {
+		msg += " on instance " + e.Instance
+	}
+	if e.Task != "" {
+		msg += " (task " + e.Task + ")"
+	}
+	if e.Error != "" {
+		msg += ": " + e.Error
+	}
+	return msg
+}
+
+// gluonTasks returns the IDs of all of the director tasks that Gluon's
+// own Jobs have started on director.
+func (w *EventWatcher) gluonTasks(director v1alpha1.Director) (map[string]bool, error) {
+	opts := []client.ListOption{client.MatchingLabels(director.DirectorLabels())}
+	if _, cluster := director.(*v1alpha1.ClusterBOSHDirector); !cluster {
+		opts = append(opts, client.InNamespace(director.GetNamespace()))
+	}
+
+	jobs := &batchv1.JobList{}
+	if err := w.List(context.Background(), jobs, opts...); err != nil {
+		return nil, err
+	}
+
+	tasks := make(map[string]bool)
+	for _, job := range jobs.Items {
+		if id := job.Annotations[v1alpha1.TaskAnnotation]; id != "" {
+			tasks[id] = true
+		}
+		for _, id := range strings.Split(job.Annotations[v1alpha1.TasksAnnotation], ",") {
+			if id != "" {
+				tasks[id] = true
+			}
+		}
+	}
+	return tasks, nil
+}
+
+// eventSubjects are the resources that director events can be about.
+type eventSubjects struct {
+	director v1alpha1.Director
+
+	deployments []v1alpha1.BOSHDeployment
+	stemcells   []v1alpha1.BOSHStemcell
+	releases    []v1alpha1.BOSHRelease
+	configs     []v1alpha1.BOSHConfig
+}
+
+// subjects finds the BOSHDeployments, BOSHStemcells, BOSHReleases and
+// BOSHConfigs that target director.
+func (w *EventWatcher) subjects(director v1alpha1.Director) (*eventSubjects, error) {
+	ctx := context.Background()
+	name, cluster := director.GetName(), ""
+	opts := []client.ListOption{}
+	if _, ok := director.(*v1alpha1.ClusterBOSHDirector); ok {
+		name, cluster = "", director.GetName()
+	} else {
+		opts = append(opts, client.InNamespace(director.GetNamespace()))
+	}
+	targets := func(d, c string) bool {
+		return d == name && c == cluster
+	}
+
+	s := &eventSubjects{director: director}
+
+	deployments := &v1alpha1.BOSHDeploymentList{}
+	if err := w.List(ctx, deployments, opts...); err != nil {
+		return nil, err
+	}
+	for _, bd := range deployments.Items {
+		if targets(bd.Spec.Director, bd.Spec.ClusterDirector) {
+			s.deployments = append(s.deployments, bd)
+		}
+	}
+
+	stemcells := &v1alpha1.BOSHStemcellList{}
+	if err := w.List(ctx, stemcells, opts...); err != nil {
+		return nil, err
+	}
+	for _, bs := range stemcells.Items {
+		if bs.Targets(name, cluster) {
+			s.stemcells = append(s.stemcells, bs)
+		}
+	}
+
+	releases := &v1alpha1.BOSHReleaseList{}
+	if err := w.List(ctx, releases, opts...); err != nil {
+		return nil, err
+	}
+	for _, br := range releases.Items {
+		if br.Targets(name, cluster) {
+			s.releases = append(s.releases, br)
+		}
+	}
+
+	configs := &v1alpha1.BOSHConfigList{}
+	if err := w.List(ctx, configs, opts...); err != nil {
+		return nil, err
+	}
+	for _, bc := range configs.Items {
+		if targets(bc.Spec.Director, bc.Spec.ClusterDirector) {
+			s.configs = append(s.configs, bc)
+		}
+	}
+
+	return s, nil
+}
+
+// about returns the resource that a director event is about, or nil if
+// Gluon doesn't manage it.
+func (s *eventSubjects) about(e bosh.Event) runtime.Object {
+	switch {
+	case e.ObjectType == "stemcell":
+		// stemcells are named as name/version
+		name, version := e.ObjectName, ""
+		if i := strings.Index(name, "/"); i >= 0 {
+			name, version = name[:i], name[i+1:]
+		}
+		for i := range s.stemcells {
+			bs := &s.stemcells[i]
+			if uploadedStemcell(bs, s.director, name, version) {
+				return bs
+			}
+		}
+		return nil
+
+	case e.ObjectType == "release":
+		// releases are named as name/version, too
+		name, version := e.ObjectName, ""
+		if i := strings.Index(name, "/"); i >= 0 {
+			name, version = name[:i], name[i+1:]
+		}
+		for i := range s.releases {
+			br := &s.releases[i]
+			if uploadedRelease(br, s.director, name, version) {
+				return br
+			}
+		}
+		return nil
+
+	case e.ObjectType == "config" || strings.HasSuffix(e.ObjectType, "-config"):
+		// older directors log cloud-config, runtime-config, etc.;
+		// newer ones log config, with a name of type/name (or just name)
+		typ, name := strings.TrimSuffix(e.ObjectType, "-config"), e.ObjectName
+		if e.ObjectType == "config" {
+			typ = ""
+			if i := strings.Index(name, "/"); i >= 0 {
+				typ, name = name[:i], name[i+1:]
+			}
+		}
+		if name == "" {
+			name = "default"
+		}
+		for i := range s.configs {
+			bc := &s.configs[i]
+			if (typ == "" || bc.Spec.Type == typ) && bc.ConfigName() == name {
+				return bc
+			}
+		}
+		return nil
+
+	case e.Deployment != "":
+		for i := range s.deployments {
+			if s.deployments[i].Name == e.Deployment {
+				return &s.deployments[i]
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+// uploadedStemcell returns true if the named stemcell is one that bs put
+// on director (or is about to).  Stemcells picked out of the stemcell
+// index (or only named by their tarball) go by what was uploaded.
+func uploadedStemcell(bs *v1alpha1.BOSHStemcell, director v1alpha1.Director, name, version string) bool {
+	for _, s := range bs.Status.Directors {
+		if u := s.Uploaded; s.Is(director) && u != nil && u.Name == name && (version == "" || u.Version == version) {
+			return true
+		}
+	}
+	return bs.Spec.Name == name && (bs.Spec.Version == "" || version == "" || bs.Spec.Version == version)
+}
+
+// uploadedRelease returns true if the named release is one that br put on
+// director (or is about to).
+func uploadedRelease(br *v1alpha1.BOSHRelease, director v1alpha1.Director, name, version string) bool {
+	for _, s := range br.Status.Directors {
+		if u := s.Uploaded; s.Is(director) && u != nil && u.Name == name && (version == "" || u.Version == version) {
+			return true
+		}
+	}
+	return br.Spec.Name == name && (br.Spec.Version == "" || version == "" || br.Spec.Version == version)
+}
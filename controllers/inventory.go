@@ -0,0 +1,196 @@
+package controllers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/starkandwayne/gluon-controller/api/v1alpha1"
+	"github.com/starkandwayne/gluon-controller/bosh"
+)
+
+// InventoryInterval is how often Gluon asks each director what is on it.
+var InventoryInterval = 5 * time.Minute
+
+// DirectorClient returns a BOSH API client for the director whose
+// credentials (endpoint, username, password and ca) are in the named Secret.
+//
+// Clients are kept around, and handed out again for as long as the Secret
+// stays the same (i.e. keeps its resourceVersion), so that each of them
+// only has to ask the director how to authenticate (and log into UAA)
+// once, rather than on every call; UAA tokens are reused until they are
+// about to expire.
+func DirectorClient(c client.Client, ns, name string) (*bosh.Client, error) {
+	secret := &corev1.Secret{}
+	err := c.Get(context.Background(), types.NamespacedName{Namespace: ns, Name: name}, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	key := types.NamespacedName{Namespace: ns, Name: name}
+	directorClients.Lock()
+	defer directorClients.Unlock()
+	if cached, ok := directorClients.m[key]; ok && cached.version == secret.ResourceVersion {
+		return cached.client, nil
+	}
+
+	d, err := bosh.New(bosh.Config{
+		Endpoint: string(secret.Data["endpoint"]),
+		Username: string(secret.Data["username"]),
+		Password: string(secret.Data["password"]),
+		CA:       string(secret.Data["ca"]),
+	})
+	if err != nil {
+		return nil, err
+	}
+	directorClients.m[key] = cachedClient{version: secret.ResourceVersion, client: d}
+	return d, nil
+}
+
+type cachedClient struct {
+	version string
+	client  *bosh.Client
+}
+
+// directorClients holds the clients DirectorClient has handed out, by
+// Secret.
+var directorClients = struct {
+	sync.Mutex
+	m map[types.NamespacedName]cachedClient
+}{m: map[types.NamespacedName]cachedClient{}}
+
+// InventoryDue returns how long until the inventory should be taken again;
+// zero (or less) means it is due now.
+func InventoryDue(inventory *v1alpha1.DirectorInventory) time.Duration {
+	if inventory == nil || inventory.CheckedAt == nil {
+		return 0
+	}
+	return time.Until(inventory.CheckedAt.Add(InventoryInterval))
+}
+
+// Inventory asks the director whose credentials are in the named Secret
+// what is actually on it.  Problems talking to the director are reported
+// in the inventory, rather than returned as errors.
+func Inventory(c client.Client, ns, secret string) *v1alpha1.DirectorInventory {
+	now := metav1.Now()
+	inventory := &v1alpha1.DirectorInventory{CheckedAt: &now}
+
+	director, err := DirectorClient(c, ns, secret)
+	if err == nil {
+		err = takeInventory(director, inventory)
+	}
+	if err != nil {
+		inventory.Error = err.Error()
+	}
+	return inventory
+}
+
+func takeInventory(director *bosh.Client, inventory *v1alpha1.DirectorInventory) error {
+	info, err := director.Info()
+	if err != nil {
+		return err
+	}
+	inventory.Reachable = true
+	inventory.Name = info.Name
+	inventory.UUID = info.UUID
+	inventory.Version = info.Version
+	inventory.CPI = info.CPI
+
+	deployments, err := director.Deployments()
+	if err != nil {
+		return err
+	}
+	for _, d := range deployments {
+		deployment := v1alpha1.InventoryDeployment{Name: d.Name}
+		tasks, err := director.RecentTasks(d.Name, 1)
+		if err != nil {
+			return err
+		}
+		if len(tasks) > 0 {
+			task := inventoryTask(tasks[0])
+			deployment.LastTask = &task
+		}
+		inventory.Deployments = append(inventory.Deployments, deployment)
+	}
+
+	stemcells, err := director.Stemcells()
+	if err != nil {
+		return err
+	}
+	for _, s := range stemcells {
+		stemcell := v1alpha1.InventoryStemcell{
+			Name:    s.Name,
+			Version: s.Version,
+			OS:      s.OperatingSystem,
+		}
+		for _, d := range s.Deployments {
+			stemcell.Deployments = append(stemcell.Deployments, d.Name)
+		}
+		inventory.Stemcells = append(inventory.Stemcells, stemcell)
+	}
+
+	releases, err := director.Releases()
+	if err != nil {
+		return err
+	}
+	for _, r := range releases {
+		release := v1alpha1.InventoryRelease{Name: r.Name}
+		for _, v := range r.Versions {
+			release.Versions = append(release.Versions, v.Version)
+		}
+		inventory.Releases = append(inventory.Releases, release)
+	}
+
+	tasks, err := director.CurrentTasks()
+	if err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		inventory.Tasks = append(inventory.Tasks, inventoryTask(t))
+	}
+
+	locks, err := director.Locks()
+	if err != nil {
+		return err
+	}
+	for _, l := range locks {
+		inventory.Locks = append(inventory.Locks, v1alpha1.InventoryLock{
+			Type:     l.Type,
+			Resource: l.Resource,
+			TaskID:   l.TaskID,
+		})
+	}
+
+	return nil
+}
+
+func inventoryTask(t bosh.Task) v1alpha1.InventoryTask {
+	return v1alpha1.InventoryTask{
+		ID:          t.ID,
+		State:       t.State,
+		Description: t.Description,
+		User:        t.User,
+		Deployment:  t.Deployment,
+	}
+}
+
+// LastTask asks the director for the most recent task run against a
+// deployment.  If there isn't one, or the director cannot be reached,
+// LastTask returns nil.
+func LastTask(c client.Client, ns string, director v1alpha1.Director, deployment string) *v1alpha1.InventoryTask {
+	d, err := DirectorClient(c, ns, director.SecretsName())
+	if err != nil {
+		return nil
+	}
+	tasks, err := d.RecentTasks(deployment, 1)
+	if err != nil || len(tasks) == 0 {
+		return nil
+	}
+	task := inventoryTask(tasks[0])
+	return &task
+}
@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	v1alpha1 "github.com/starkandwayne/gluon-controller/api/v1alpha1"
+)
+
+// +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;watch;create;update;patch;delete
+
+// StateLockGrace is how long a state lock is held for a Job that cannot
+// be found.  The lock is taken before the holder Job is created, so for
+// a little while a missing holder is most likely one that is about to
+// exist, not one that is long gone.
+const StateLockGrace = 2 * time.Minute
+
+// AcquireStateLock tries to take the lock on the create-env state of the
+// BOSH director bd, on behalf of the Job named holder.  The lock is a
+// Lease; creating it (or taking it over) relies on the API server's
+// optimistic concurrency, so only one holder can ever win.
+//
+// A lock held by a Job that has since finished (or been deleted, and
+// not just now, see StateLockGrace) is up for grabs.  The holder is
+// looked up through QueueReader, if set, so that a Job created a moment
+// ago by another controller is not mistaken for a missing one.  If the lock cannot be had, AcquireStateLock returns false,
+// and the name of the Job currently holding it.
+func AcquireStateLock(c client.Client, scheme *runtime.Scheme, bd *v1alpha1.BOSHDeployment, holder string) (bool, string, error) {
+	ctx := context.Background()
+	now := metav1.NewMicroTime(time.Now())
+
+	lease := &coordinationv1.Lease{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: bd.Namespace, Name: bd.StateLockName()}, lease)
+	if errors.IsNotFound(err) {
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: bd.Namespace,
+				Name:      bd.StateLockName(),
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity: &holder,
+				AcquireTime:    &now,
+			},
+		}
+		if err := controllerutil.SetControllerReference(bd, lease, scheme); err != nil {
+			return false, "", err
+		}
+		if err := c.Create(ctx, lease); err != nil {
+			if errors.IsAlreadyExists(err) {
+				// someone beat us to it
+				return false, "", nil
+			}
+			return false, "", err
+		}
+		return true, holder, nil
+
+	} else if err != nil {
+		return false, "", err
+	}
+
+	current := ""
+	if lease.Spec.HolderIdentity != nil {
+		current = *lease.Spec.HolderIdentity
+	}
+	if current == holder {
+		return true, holder, nil
+	}
+
+	// is the current holder still around, and still working?
+	if current != "" {
+		var r client.Reader = c
+		if QueueReader != nil {
+			r = QueueReader
+		}
+
+		job := &batchv1.Job{}
+		err = r.Get(ctx, types.NamespacedName{Namespace: bd.Namespace, Name: current}, job)
+		if err == nil && !Finished(job) {
+			return false, current, nil
+		} else if errors.IsNotFound(err) {
+			if lease.Spec.AcquireTime != nil && now.Sub(lease.Spec.AcquireTime.Time) < StateLockGrace {
+				return false, current, nil
+			}
+		} else if err != nil {
+			return false, current, err
+		}
+	}
+
+	// the lock is stale; take it over
+	lease.Spec.HolderIdentity = &holder
+	lease.Spec.AcquireTime = &now
+	if err := c.Update(ctx, lease); err != nil {
+		if errors.IsConflict(err) {
+			return false, current, nil
+		}
+		return false, current, err
+	}
+	return true, holder, nil
+}
+
+// ReleaseStateLock gives up the lock on the create-env state of the BOSH
+// director bd, but only if it is currently held by holder.
+func ReleaseStateLock(c client.Client, bd *v1alpha1.BOSHDeployment, holder string) error {
+	ctx := context.Background()
+
+	lease := &coordinationv1.Lease{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: bd.Namespace, Name: bd.StateLockName()}, lease)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != holder {
+		return nil
+	}
+
+	lease.Spec.HolderIdentity = nil
+	lease.Spec.AcquireTime = nil
+	if err := c.Update(ctx, lease); err != nil && !errors.IsConflict(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/starkandwayne/gluon-controller/api/v1alpha1"
+)
+
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+
+// QueueRetryAfter is how long an operation that has to wait its turn on a
+// busy director waits before checking again.
+const QueueRetryAfter = 15 * time.Second
+
+// RetryAfter is how long a failed Job that has to be run again is left
+// around (so that its logs can be looked at) before it is.  Each retry
+// waits twice as long as the one before it, up to RetryMax.
+const (
+	RetryAfter = time.Minute
+	RetryMax   = time.Hour
+)
+
+// QueueReader, if set, reads Jobs straight from the API server when
+// CreateQueued double-checks the queue, and when AcquireStateLock checks
+// up on the current holder of a lock.  The informer cache behind the
+// usual client may not have seen Jobs created a moment ago, by another
+// reconcile.
+var QueueReader client.Reader
+
+// Queued determines whether or not a new operation against director has
+// to wait its turn, based on how many Jobs for the same kind of operation
+// are still running against that director.
+//
+// Jobs for cluster directors are counted across all namespaces; jobs for
+// namespaced directors only within the director's own namespace.
+func Queued(c client.Client, director v1alpha1.Director, operation string) (bool, error) {
+	running, err := running(c, director, operation, nil)
+	if err != nil {
+		return false, err
+	}
+	return running >= director.MaxConcurrent(operation), nil
+}
+
+// CreateQueued creates job, an operation against director that Queued
+// has already let through, and then checks the queue again.  Two
+// reconciles that run close together can both find the director free;
+// if any other Job for the same kind of operation is now running in
+// job's place, job is deleted again, and CreateQueued returns false so
+// that the caller can queue up and try again later.  (Both may back
+// off, but never both go ahead.)
+func CreateQueued(c client.Client, director v1alpha1.Director, operation string, job *batchv1.Job) (bool, error) {
+	ctx := context.Background()
+	if err := c.Create(ctx, job); err != nil {
+		return false, err
+	}
+
+	var r client.Reader = c
+	if QueueReader != nil {
+		r = QueueReader
+	}
+	others, err := running(r, director, operation, job)
+	if err != nil {
+		return false, err
+	}
+	if others < director.MaxConcurrent(operation) {
+		return true, nil
+	}
+
+	background := metav1.DeletePropagationBackground
+	if err := c.Delete(ctx, job, &client.DeleteOptions{PropagationPolicy: &background}); err != nil && !errors.IsNotFound(err) {
+		return false, err
+	}
+	return false, nil
+}
+
+// running counts the unfinished Jobs for operation against director,
+// other than except (if given) and any that are being deleted already.
+func running(r client.Reader, director v1alpha1.Director, operation string, except *batchv1.Job) (int, error) {
+	labels := client.MatchingLabels(director.DirectorLabels())
+	labels[v1alpha1.OperationLabel] = operation
+
+	opts := []client.ListOption{labels}
+	if director.GetNamespace() != "" {
+		opts = append(opts, client.InNamespace(director.GetNamespace()))
+	}
+
+	jobs := &batchv1.JobList{}
+	if err := r.List(context.Background(), jobs, opts...); err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, job := range jobs.Items {
+		if except != nil && job.Namespace == except.Namespace && job.Name == except.Name {
+			continue
+		}
+		if except != nil && !job.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if !Finished(&job) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Finished returns true if the given Job has either completed or failed.
+func Finished(job *batchv1.Job) bool {
+	for _, c := range job.Status.Conditions {
+		if (c.Type == batchv1.JobComplete || c.Type == batchv1.JobFailed) && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// RetryFailed re-runs a failed Job, once it has waited out its backoff, by
+// deleting it so that the next reconcile creates it anew; status counts
+// the retries.  It returns how long to wait before checking again.
+func RetryFailed(c client.Client, job *batchv1.Job, status *v1alpha1.DirectorStatus) (time.Duration, error) {
+	wait := RetryMax
+	if status.Retries < 6 {
+		wait = RetryAfter << uint(status.Retries)
+	}
+
+	failed := job.CreationTimestamp.Time
+	for _, cond := range job.Status.Conditions {
+		if cond.Type == batchv1.JobFailed && cond.Status == corev1.ConditionTrue {
+			failed = cond.LastTransitionTime.Time
+		}
+	}
+	if left := time.Until(failed.Add(wait)); left > 0 {
+		return left, nil
+	}
+
+	background := metav1.DeletePropagationBackground
+	if err := c.Delete(context.Background(), job, &client.DeleteOptions{PropagationPolicy: &background}); err != nil && !errors.IsNotFound(err) {
+		return 0, err
+	}
+	status.Retries++
+	return QueueRetryAfter, nil
+}
+
+// DeploymentLocked asks the director whether something outside of Gluon
+// (i.e. a `bosh deploy` run by hand) already holds the lock on a
+// deployment, and if so, which task holds it.  Directors that cannot be
+// reached are treated as unlocked; the Job will find out soon enough.
+func DeploymentLocked(c client.Client, ns string, director v1alpha1.Director, deployment string) (bool, string) {
+	d, err := DirectorClient(c, ns, director.SecretsName())
+	if err != nil {
+		return false, ""
+	}
+	lock, err := d.DeploymentLock(deployment)
+	if err != nil || lock == nil {
+		return false, ""
+	}
+	return true, lock.TaskID
+}
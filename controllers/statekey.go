@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/starkandwayne/gluon-controller/api/v1alpha1"
+)
+
+// StateKeySecret is the name of the Secret (in the Gluon namespace) that
+// holds the key that every create-env director's state key is derived
+// from.  It is generated the first time it is needed.
+var StateKeySecret = "gluon-state-key"
+
+// EnsureStateKey makes sure that the create-env director bd has a state
+// key, which its Jobs encrypt its vars-store (creds.yml) with wherever
+// they save it off to a Secret.
+//
+// Each director's key is derived from the controller's own key, so that
+// a lost (or deleted) director key can always be derived again, so long
+// as the controller's key is kept safe.  Keys that are already there are
+// left alone.
+func EnsureStateKey(c client.Client, bd *v1alpha1.BOSHDeployment) error {
+	ctx := context.Background()
+
+	secret := &corev1.Secret{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: bd.Namespace, Name: bd.StateKeySecretName()}, secret)
+	if err == nil {
+		return nil
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+
+	master, err := stateKey(c)
+	if err != nil {
+		return err
+	}
+	mac := hmac.New(sha256.New, master)
+	mac.Write([]byte(bd.Namespace + "/" + bd.Name))
+
+	// (no owner; the key has to outlive the BOSHDeployment for as long
+	// as the state it encrypts does)
+	secret = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: bd.Namespace,
+			Name:      bd.StateKeySecretName(),
+		},
+		StringData: map[string]string{
+			"key": hex.EncodeToString(mac.Sum(nil)),
+		},
+	}
+	if err := c.Create(ctx, secret); err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+// stateKey returns the controller's own key, generating it if need be.
+func stateKey(c client.Client) ([]byte, error) {
+	ctx := context.Background()
+
+	var r client.Reader = c
+	if QueueReader != nil {
+		r = QueueReader
+	}
+
+	secret := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: v1alpha1.GluonNamespace, Name: StateKeySecret}, secret)
+	if err == nil {
+		return secret.Data["key"], nil
+	} else if !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	secret = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: v1alpha1.GluonNamespace,
+			Name:      StateKeySecret,
+		},
+		Data: map[string][]byte{
+			"key": key,
+		},
+	}
+	if err := c.Create(ctx, secret); err != nil {
+		if errors.IsAlreadyExists(err) {
+			// someone beat us to it; use theirs
+			return stateKey(c)
+		}
+		return nil, err
+	}
+	return key, nil
+}
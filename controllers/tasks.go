@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/starkandwayne/gluon-controller/api/v1alpha1"
+	"github.com/starkandwayne/gluon-controller/bosh"
+)
+
+// TaskPollInterval is how often Gluon checks in on running director tasks.
+const TaskPollInterval = 10 * time.Second
+
+// TrackTask follows the director task that a Job has reported starting
+// (see v1alpha1.TaskAnnotation).  If the Job hasn't started one (yet),
+// TrackTask returns nil.
+func TrackTask(c client.Client, ns string, director v1alpha1.Director, job *batchv1.Job) (*v1alpha1.TaskStatus, error) {
+	id, err := strconv.Atoi(job.Annotations[v1alpha1.TaskAnnotation])
+	if err != nil {
+		return nil, nil
+	}
+
+	d, err := DirectorClient(c, ns, director.SecretsName())
+	if err != nil {
+		return nil, err
+	}
+	task, err := d.Task(id)
+	if err != nil {
+		return nil, err
+	}
+	events, err := d.TaskEvents(id)
+	if err != nil {
+		return nil, err
+	}
+	return taskStatus(task, events), nil
+}
+
+func taskStatus(task bosh.Task, events []bosh.TaskEvent) *v1alpha1.TaskStatus {
+	status := &v1alpha1.TaskStatus{
+		ID:          task.ID,
+		State:       task.State,
+		Description: task.Description,
+	}
+
+	for _, e := range events {
+		if e.Error != nil {
+			status.Error = e.Error.Message
+			continue
+		}
+		if e.Stage == "" {
+			continue
+		}
+
+		status.Stage = e.Stage
+		status.InstanceGroup = ""
+		if len(e.Tags) > 0 {
+			status.InstanceGroup = e.Tags[0]
+		}
+		status.Progress = ""
+		if e.Total > 0 {
+			status.Progress = fmt.Sprintf("%d/%d", e.Index, e.Total)
+		}
+		if e.State == "failed" {
+			// stop here, so that we report where things went wrong
+			status.Error = e.Data.Error
+			break
+		}
+	}
+
+	summary := strings.ToLower(status.Stage)
+	if status.InstanceGroup != "" {
+		summary += " " + status.InstanceGroup
+	}
+	if status.Progress != "" {
+		summary += " (" + status.Progress + ")"
+	}
+
+	switch {
+	case task.State == bosh.TaskDone || summary == "":
+		status.Summary = task.State
+	case task.Finished():
+		status.Summary = task.State + " " + summary
+	default:
+		status.Summary = summary
+	}
+	return status
+}
+
+// CancelJob cancels the director task that a Job has started (if any),
+// and once that task has stopped, deletes the Job.  It returns true once
+// the Job is gone (or on its way out).
+func CancelJob(c client.Client, ns string, director v1alpha1.Director, job *batchv1.Job) (bool, error) {
+	if id, err := strconv.Atoi(job.Annotations[v1alpha1.TaskAnnotation]); err == nil {
+		d, err := DirectorClient(c, ns, director.SecretsName())
+		if err != nil {
+			return false, err
+		}
+		task, err := d.Task(id)
+		if err != nil {
+			return false, err
+		}
+		if !task.Finished() {
+			if task.State != bosh.TaskCancelling {
+				if err := d.CancelTask(id); err != nil {
+					return false, err
+				}
+			}
+			return false, nil
+		}
+	}
+
+	err := c.Delete(context.Background(), job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	if err != nil && !errors.IsNotFound(err) {
+		return false, err
+	}
+	return true, nil
+}
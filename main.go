@@ -54,10 +54,25 @@ func init() {
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
+	var alertsAddr, alertsCert, alertsKey string
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.DurationVar(&controllers.InventoryInterval, "inventory-interval", controllers.InventoryInterval,
+		"How often to ask each BOSH director what is deployed / uploaded to it.")
+	flag.StringVar(&alertsAddr, "alerts-addr", ":8081", "The address the BOSH health monitor alert receiver binds to.")
+	flag.StringVar(&controllers.AlertsURL, "alerts-url", "",
+		"The (https) URL that BOSH health monitors should forward alerts to (i.e. where --alerts-addr can be reached).")
+	flag.StringVar(&alertsCert, "alerts-tls-cert", "",
+		"The TLS certificate for the alert receiver to serve HTTPS with; without one, it serves plain HTTP.")
+	flag.StringVar(&alertsKey, "alerts-tls-key", "", "The private key for --alerts-tls-cert.")
+	flag.DurationVar(&controllers.EventsInterval, "events-interval", controllers.EventsInterval,
+		"How often to check BOSH directors (with spec.watchEvents set) for new events.")
+	flag.DurationVar(&controllers.StemcellVerifyInterval, "stemcell-verify-interval", controllers.StemcellVerifyInterval,
+		"How often to check that uploaded stemcells are still on their BOSH directors.")
+	flag.StringVar(&controllers.StemcellIndex, "stemcell-index", controllers.StemcellIndex,
+		"The bosh.io stemcell index (or a mirror of it) to resolve stemcells given by OS / version / IaaS against.")
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
@@ -74,6 +89,9 @@ func main() {
 		os.Exit(1)
 	}
 
+	// double-check director queues against the API server, not the cache
+	controllers.QueueReader = mgr.GetAPIReader()
+
 	if err = (&controllers.BOSHDeploymentReconciler{
 		Client: mgr.GetClient(),
 		Log:    ctrl.Log.WithName("controllers").WithName("BOSHDeployment"),
@@ -83,11 +101,29 @@ func main() {
 		os.Exit(1)
 	}
 	if err = (&controllers.BOSHStemcellReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("BOSHStemcell"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("boshstemcell-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "BOSHStemcell")
+		os.Exit(1)
+	}
+	if err = (&controllers.BOSHReleaseReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("BOSHRelease"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("boshrelease-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "BOSHRelease")
+		os.Exit(1)
+	}
+	if err = (&controllers.BOSHCompiledReleaseReconciler{
 		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("BOSHStemcell"),
+		Log:    ctrl.Log.WithName("controllers").WithName("BOSHCompiledRelease"),
 		Scheme: mgr.GetScheme(),
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "BOSHStemcell")
+		setupLog.Error(err, "unable to create controller", "controller", "BOSHCompiledRelease")
 		os.Exit(1)
 	}
 	if err = (&controllers.BOSHConfigReconciler{
@@ -98,8 +134,86 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "BOSHConfig")
 		os.Exit(1)
 	}
+	if err = (&controllers.ClusterBOSHDirectorReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("ClusterBOSHDirector"),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterBOSHDirector")
+		os.Exit(1)
+	}
+	if err = (&controllers.BOSHDirectorBackupReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("BOSHDirectorBackup"),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "BOSHDirectorBackup")
+		os.Exit(1)
+	}
+	if err = (&controllers.BOSHDirectorRestoreReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("BOSHDirectorRestore"),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "BOSHDirectorRestore")
+		os.Exit(1)
+	}
+	if err = (&controllers.BOSHStemcellPolicyReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("BOSHStemcellPolicy"),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "BOSHStemcellPolicy")
+		os.Exit(1)
+	}
+	if err = (&controllers.BOSHArtifactCacheReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("BOSHArtifactCache"),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "BOSHArtifactCache")
+		os.Exit(1)
+	}
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&gluonv1alpha1.BOSHDeployment{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "BOSHDeployment")
+			os.Exit(1)
+		}
+		if err = (&gluonv1alpha1.BOSHStemcell{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "BOSHStemcell")
+			os.Exit(1)
+		}
+		if err = (&gluonv1alpha1.BOSHRelease{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "BOSHRelease")
+			os.Exit(1)
+		}
+		if err = (&gluonv1alpha1.BOSHConfig{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "BOSHConfig")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
+	if err = mgr.Add(&controllers.AlertReceiver{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("alerts"),
+		Recorder: mgr.GetEventRecorderFor("gluon-alerts"),
+		Addr:     alertsAddr,
+		TLSCert:  alertsCert,
+		TLSKey:   alertsKey,
+	}); err != nil {
+		setupLog.Error(err, "unable to create alert receiver")
+		os.Exit(1)
+	}
+	if err = mgr.Add(&controllers.EventWatcher{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("events"),
+		Recorder: mgr.GetEventRecorderFor("gluon-events"),
+	}); err != nil {
+		setupLog.Error(err, "unable to create director event watcher")
+		os.Exit(1)
+	}
+
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		setupLog.Error(err, "problem running manager")